@@ -7,24 +7,19 @@ import (
 	"github.com/231tr0n/vault/internal/cli"
 )
 
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, "-----------------")
+	fmt.Fprintln(os.Stderr, err)
+	fmt.Fprintln(os.Stderr, "-----------------")
+	os.Exit(cli.ExitCode(err))
+}
+
 func main() {
 	if err := cli.Init(); err != nil {
-		//nolint
-		fmt.Println("-----------------")
-		//nolint
-		fmt.Println(err)
-		//nolint
-		fmt.Println("-----------------")
-		os.Exit(0)
+		fail(err)
 	}
 
 	if err := cli.Parse(); err != nil {
-		//nolint
-		fmt.Println("-----------------")
-		//nolint
-		fmt.Println(err)
-		//nolint
-		fmt.Println("-----------------")
-		os.Exit(0)
+		fail(err)
 	}
 }
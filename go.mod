@@ -2,6 +2,8 @@ module github.com/231tr0n/vault
 
 go 1.20
 
-require golang.org/x/term v0.11.0
-
-require golang.org/x/sys v0.11.0 // indirect
+require (
+	golang.org/x/crypto v0.12.0
+	golang.org/x/sys v0.11.0
+	golang.org/x/term v0.11.0
+)
@@ -1,14 +1,16 @@
 package cli
 
 import (
+	"errors"
 	"flag"
 	"fmt"
-	"syscall"
+	"io"
+	"os"
+	"sort"
+	"strings"
 
 	"github.com/231tr0n/vault/config"
-	"github.com/231tr0n/vault/pkg/crypto"
 	"github.com/231tr0n/vault/pkg/passwdstore"
-	"golang.org/x/term"
 )
 
 func wrap(err error) error {
@@ -19,226 +21,275 @@ func wrap(err error) error {
 	return nil
 }
 
+// vault is the password store opened by Init.
+var vault *passwdstore.Vault
+
+var (
+	// errInvalidField is the error thrown when a custom field is not of the form name=value.
+	errInvalidField = errors.New("cli: custom field not of the form name=value")
+	// errPasswdMismatch is the error thrown when the new vault password is not re-entered correctly.
+	errPasswdMismatch = errors.New("cli: new vault passwords don't match")
+	// errNoOTP is the error thrown when the entry has no key for one-time codes.
+	errNoOTP = errors.New("cli: entry has no otp key, set it with 'vault put -otp'")
+)
+
 // Init initlialises the passwdstore.
 func Init() error {
-	return wrap(passwdstore.Init(config.GetPasswdStoreFilePath()))
-}
-
-func readSecureInput(c string) ([]byte, error) {
-	//nolint
-	fmt.Print(c)
+	v, err := passwdstore.Open(config.GetPasswdStoreFilePath(), nil)
+	if err != nil {
+		return wrap(err)
+	}
 
-	//nolint
-	s, err := term.ReadPassword(int(syscall.Stdin))
+	vault = v
 
-	//nolint
-	fmt.Println()
+	return nil
+}
 
-	return s, wrap(err)
+// command is a subcommand of the vault like "vault get".
+type command struct {
+	name    string
+	aliases []string
+	// args is the synopsis of the positional arguments shown in the usage.
+	args string
+	// minArgs and maxArgs are the number of positional arguments the command takes.
+	minArgs int
+	maxArgs int
+	desc    string
+	fs      *flag.FlagSet
+	// out is set for the commands which print results in different formats.
+	out *output
+	// passwd is set for the commands which read the vault password.
+	passwd *passwdSource
+	// run runs the command with the positional arguments left after parsing the flags.
+	run func(args []string) error
 }
 
-// Parse parses the command line arguments and runs the respective functions accordingly.
-func Parse() error {
-	change := flag.Bool("change", false, "Changes the vault password.")
-	list := flag.Bool("list", false, "Lists all the password names in the vault.")
-	listAll := flag.Bool("list-all", false, "Lists all the passwords with names(dangerous) in the vault.")
-	clear := flag.Bool("clear", false, "Clears all the passwords in the vault.")
-	get := flag.String("get", "", "Gets the password from the vault.")
-	put := flag.String("put", "", "Puts the password in the vault.")
-	del := flag.String("delete", "", "Deletes the password in the vault.")
-	//nolint
-	generate := flag.Int("generate", 0, "Generates a new random password of length given. If this flag is passed along with put flag, it generates a random password and stores that in the vault.")
-
-	flag.Parse()
-
-	//nolint
-	fmt.Println("-----------------")
-	//nolint
-	fmt.Println("Vault")
-	//nolint
-	fmt.Println("-----------------")
-
-switch1:
-	switch {
-	case *clear:
-		pwd, err := readSecureInput("Enter vault password: ")
-		if err != nil {
-			return wrap(err)
-		}
-		err = passwdstore.Clear(pwd)
-		if err != nil {
-			return wrap(err)
+// newCommand returns a command with an empty flag set whose usage prints the synopsis, description and flags.
+func newCommand(name, args, desc string) *command {
+	c := &command{
+		name: name,
+		args: args,
+		desc: desc,
+		fs:   flag.NewFlagSet(name, flag.ContinueOnError),
+	}
+
+	c.fs.Usage = func() {
+		w := c.fs.Output()
+		fmt.Fprintf(w, "Usage: %s\n\n%s\n", strings.TrimSpace("vault "+c.name+" [flags] "+c.args), c.desc)
+
+		if len(c.aliases) > 0 {
+			fmt.Fprintf(w, "\nAliases: %s\n", strings.Join(c.aliases, ", "))
 		}
 
-		//nolint
-		fmt.Println("-----------------")
-		//nolint
-		fmt.Println("Vault cleared")
+		hasFlags := false
 
-	case *change:
-		oldPwd, err := readSecureInput("Enter old vault password: ")
-		if err != nil {
-			return wrap(err)
+		c.fs.VisitAll(func(*flag.Flag) {
+			hasFlags = true
+		})
+
+		if hasFlags {
+			fmt.Fprintf(w, "\nFlags:\n")
+			c.fs.PrintDefaults()
 		}
+	}
 
-		newPwd, err := readSecureInput("Enter new vault password: ")
-		if err != nil {
-			return wrap(err)
+	return c
+}
+
+// commands returns all the subcommands of the vault sorted by name.
+func commands() []*command {
+	cmds := []*command{
+		getCommand(),
+		putCommand(),
+		rmCommand(),
+		lsCommand(),
+		mvCommand(),
+		cpCommand(),
+		searchCommand(),
+		historyCommand(),
+		restoreCommand(),
+		passwdCommand(),
+		genCommand(),
+		otpCommand(),
+		clearCommand(),
+		fixPermissionsCommand(),
+	}
+
+	sort.Slice(cmds, func(i, j int) bool {
+		return cmds[i].name < cmds[j].name
+	})
+
+	return cmds
+}
+
+// findCommand returns the subcommand with the name or alias "name".
+func findCommand(name string) *command {
+	for _, c := range commands() {
+		if c.name == name {
+			return c
 		}
 
-		newPwdCheck, err := readSecureInput("Re-Enter new vault password: ")
-		if err != nil {
-			return wrap(err)
+		for _, alias := range c.aliases {
+			if alias == name {
+				return c
+			}
 		}
+	}
 
-		if string(newPwd) != string(newPwdCheck) {
-			//nolint
-			fmt.Println("-----------------")
-			//nolint
-			fmt.Println("New vault passwords don't match")
+	return nil
+}
 
-			break switch1
-		}
+// usage prints the usage of the vault listing all the subcommands.
+func usage(w io.Writer) {
+	fmt.Fprintf(w, "Usage: vault <command> [flags] [arguments]\n\nCommands:\n")
 
-		err = passwdstore.ChangePasswd(newPwd, oldPwd)
-		if err != nil {
-			return wrap(err)
+	for _, c := range commands() {
+		fmt.Fprintf(w, "  %-16s %s\n", c.name, c.desc)
+	}
+
+	fmt.Fprintf(w, "  %-16s %s\n", "help", "Shows the usage of the vault or of a command.")
+	fmt.Fprintf(w, "\nRun 'vault help <command>' to get the flags of a command.\n")
+}
+
+// parseArgs parses the flags in "args" which can be given before or after the positional arguments.
+// Everything after "--" is taken as positional arguments.
+func parseArgs(fs *flag.FlagSet, args []string) ([]string, error) {
+	var rest []string
+
+	for i, arg := range args {
+		if arg == "--" {
+			args, rest = args[:i], args[i+1:]
+
+			break
 		}
+	}
 
-		//nolint
-		fmt.Println("-----------------")
-		//nolint
-		fmt.Println("Vault password changed")
+	pos := make([]string, 0)
 
-	case *listAll:
-		pwd, err := readSecureInput("Enter vault password: ")
+	for {
+		err := fs.Parse(args)
 		if err != nil {
-			return wrap(err)
+			return nil, err
 		}
 
-		list, err := passwdstore.ListEntries(pwd)
-		if err != nil {
-			return wrap(err)
+		args = fs.Args()
+		if len(args) == 0 {
+			break
 		}
 
-		//nolint
-		fmt.Println("-----------------")
-		//nolint
-		fmt.Println("List of passwords")
-		//nolint
-		fmt.Println("-----------------")
+		pos = append(pos, args[0])
+		args = args[1:]
+	}
 
-		for i, val := range list {
-			//nolint
-			fmt.Println(i, val[0], val[1])
-		}
+	return append(pos, rest...), nil
+}
 
-	case *list:
-		pwd, err := readSecureInput("Enter vault password: ")
-		if err != nil {
-			return wrap(err)
-		}
+// argsCount returns the number of positional arguments in words for errors.
+func argsCount(minArgs, maxArgs int) string {
+	switch {
+	case maxArgs == 0:
+		return "no arguments"
+	case minArgs == maxArgs:
+		return fmt.Sprintf("%d argument(s)", minArgs)
+	default:
+		return fmt.Sprintf("%d to %d arguments", minArgs, maxArgs)
+	}
+}
 
-		list, err := passwdstore.ListKeys(pwd)
+// runCommand parses the flags of the subcommand and runs it.
+func runCommand(c *command, args []string) error {
+	pos, err := parseArgs(c.fs, args)
+	if errors.Is(err, flag.ErrHelp) {
+		return nil
+	}
+
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrUsage, err.Error())
+	}
+
+	if len(pos) < c.minArgs || len(pos) > c.maxArgs {
+		c.fs.Usage()
+
+		return fmt.Errorf("%w: %s takes %s", ErrUsage, c.name, argsCount(c.minArgs, c.maxArgs))
+	}
+
+	if c.out != nil {
+		err = c.out.validate()
 		if err != nil {
-			return wrap(err)
+			c.fs.Usage()
+
+			return err
 		}
+	}
 
+	if c.out.decorated() {
 		//nolint
 		fmt.Println("-----------------")
 		//nolint
-		fmt.Println("List of passwords")
+		fmt.Println("Vault")
 		//nolint
 		fmt.Println("-----------------")
+	}
 
-		for i, val := range list {
-			//nolint
-			fmt.Println(i, val)
-		}
-
-	case *get != "":
-		pwd, err := readSecureInput("Enter vault password: ")
-		if err != nil {
-			return wrap(err)
-		}
+	err = c.run(pos)
+	if errors.Is(err, ErrUsage) {
+		c.fs.Usage()
+	}
 
-		value, err := passwdstore.Get(*get, pwd)
-		if err != nil {
-			return wrap(err)
-		}
+	if err != nil {
+		return err
+	}
 
+	if c.out.decorated() {
 		//nolint
 		fmt.Println("-----------------")
-		//nolint
-		fmt.Println("Password:", value)
+	}
 
-	case *put != "":
-		pwd, err := readSecureInput("Enter vault password: ")
-		if err != nil {
-			return wrap(err)
-		}
+	return nil
+}
 
-		var value []byte
+// Parse parses the command line arguments and runs the respective subcommand accordingly.
+// The flags of the older versions like "vault -get name" are still parsed with a deprecation notice.
+func Parse() error {
+	args := os.Args[1:]
 
-		if *generate > 0 {
-			value, err = crypto.Generate(*generate)
-			if err != nil {
-				return wrap(err)
-			}
-			//nolint
-			fmt.Println("-----------------")
-			//nolint
-			fmt.Println("Generated password:", string(value))
-		} else {
-			value, err = readSecureInput("Enter password for '" + *put + "': ")
-			if err != nil {
-				return wrap(err)
-			}
-		}
+	if len(args) == 0 {
+		usage(os.Stderr)
 
-		err = passwdstore.Put(*put, string(value), pwd)
-		if err != nil {
-			return wrap(err)
-		}
+		return ErrUsage
+	}
 
-		//nolint
-		fmt.Println("-----------------")
-		//nolint
-		fmt.Println("Password stored")
+	switch args[0] {
+	case "help", "-h", "-help", "--help":
+		if len(args) > 1 {
+			c := findCommand(args[1])
+			if c == nil {
+				usage(os.Stderr)
 
-	case *del != "":
-		pwd, err := readSecureInput("Enter vault password: ")
-		if err != nil {
-			return wrap(err)
-		}
+				return fmt.Errorf("%w: unknown command %q", ErrUsage, args[1])
+			}
 
-		err = passwdstore.Delete(*del, pwd)
-		if err != nil {
-			return wrap(err)
+			c.fs.SetOutput(os.Stdout)
+			c.fs.Usage()
+
+			return nil
 		}
 
-		//nolint
-		fmt.Println("-----------------")
-		//nolint
-		fmt.Println("Password deleted")
+		usage(os.Stdout)
 
-	case *generate > 0:
-		pwd, err := crypto.Generate(*generate)
-		if err != nil {
-			return wrap(err)
-		}
+		return nil
+	}
 
-		//nolint
-		fmt.Println("-----------------")
-		//nolint
-		fmt.Println("Generated password:", string(pwd))
-	default:
-		//nolint
-		fmt.Println("No arguments given. Run -help to get a list of arguments.")
+	if strings.HasPrefix(args[0], "-") {
+		return parseLegacy(args)
 	}
 
-	//nolint
-	fmt.Println("-----------------")
+	c := findCommand(args[0])
+	if c == nil {
+		usage(os.Stderr)
 
-	return nil
+		return fmt.Errorf("%w: unknown command %q", ErrUsage, args[0])
+	}
+
+	return runCommand(c, args[1:])
 }
@@ -0,0 +1,292 @@
+package cli_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/231tr0n/vault/internal/cli"
+	"github.com/231tr0n/vault/internal/clipboard/clipboardtest"
+	"github.com/231tr0n/vault/pkg/crypto"
+	"github.com/231tr0n/vault/pkg/passwdstore"
+)
+
+func failTestCase(t *testing.T, i, o, w any) {
+	t.Helper()
+	t.Error("Input:", i, "|", "Output:", o, "|", "Want:", w)
+}
+
+func TestCopyToClipboard(t *testing.T) {
+	t.Parallel()
+
+	type test struct {
+		timeout time.Duration
+		want    string
+		err     error
+	}
+
+	tests := []test{
+		{0, "secret", nil},
+		{time.Millisecond, "", nil},
+		{-time.Second, "", cli.ErrUsage},
+	}
+
+	for _, test := range tests {
+		t.Log(test)
+
+		fake := &clipboardtest.Fake{}
+
+		err := cli.CopyToClipboard(fake, []byte("secret"), test.timeout)
+		if !errors.Is(err, test.err) {
+			failTestCase(t, test, err, test.err)
+		}
+
+		out, err := fake.Paste()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if string(out) != test.want {
+			failTestCase(t, test, string(out), test.want)
+		}
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	t.Parallel()
+
+	type test struct {
+		err  error
+		want int
+	}
+
+	tests := []test{
+		{nil, cli.ExitOK},
+		{errors.New("other"), cli.ExitError},
+		{cli.ErrUsage, cli.ExitUsage},
+		{fmt.Errorf("cli: %w", crypto.ErrInvalidPolicy), cli.ExitUsage},
+		{fmt.Errorf("cli: %w", passwdstore.ErrInvalidKey), cli.ExitUsage},
+		{fmt.Errorf("cli: %w", passwdstore.ErrInvalidPattern), cli.ExitUsage},
+		{fmt.Errorf("cli: %w", passwdstore.ErrUnknownField), cli.ExitUsage},
+		{fmt.Errorf("cli: passwdstore: %w", crypto.ErrWrongPasswd), cli.ExitWrongPasswd},
+		{fmt.Errorf("cli: %w", passwdstore.ErrPasswdFileIntegrityFail), cli.ExitIntegrityFail},
+		{fmt.Errorf("cli: %w", passwdstore.ErrPasswdFileManuallyEdited), cli.ExitIntegrityFail},
+		{fmt.Errorf("cli: %w", passwdstore.ErrUnsupportedFileVersion), cli.ExitIntegrityFail},
+		{fmt.Errorf("cli: %w", crypto.ErrInvalidKDFParams), cli.ExitIntegrityFail},
+		{fmt.Errorf("cli: %w: hi", passwdstore.ErrEntryNotFound), cli.ExitNotFound},
+		{fmt.Errorf("cli: %w", passwdstore.ErrVersionNotFound), cli.ExitNotFound},
+		{fmt.Errorf("cli: %w", passwdstore.ErrVaultLocked), cli.ExitLocked},
+		{fmt.Errorf("cli: %w", passwdstore.ErrVaultModified), cli.ExitLocked},
+		{fmt.Errorf("cli: %w", passwdstore.ErrInsecurePermissions), cli.ExitInsecurePermissions},
+		{fmt.Errorf("cli: %w", passwdstore.ErrVaultPasswdNotSet), cli.ExitPasswdNotSet},
+		{fmt.Errorf("cli: %w: b, give -force to overwrite it", passwdstore.ErrEntryExists), cli.ExitExists},
+	}
+
+	for _, test := range tests {
+		t.Log(test)
+
+		out := cli.ExitCode(test.err)
+		if out != test.want {
+			failTestCase(t, test.err, out, test.want)
+		}
+	}
+}
+
+func TestParseArgs(t *testing.T) {
+	t.Parallel()
+
+	type test struct {
+		args []string
+		pos  string
+		s    string
+		b    bool
+	}
+
+	tests := []test{
+		{[]string{"name"}, "name", "", false},
+		{[]string{"-b", "-s", "x", "name"}, "name", "x", true},
+		{[]string{"name", "-b", "-s", "x"}, "name", "x", true},
+		{[]string{"-s", "x", "from", "-b", "to"}, "from,to", "x", true},
+		{[]string{"-b", "--", "-s", "x"}, "-s,x", "", true},
+		{[]string{"name", "--", "-b"}, "name,-b", "", false},
+		{[]string{"--"}, "", "", false},
+		{[]string{}, "", "", false},
+	}
+
+	for _, test := range tests {
+		t.Log(test)
+
+		pos, s, b, err := cli.ParseArgs(test.args)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		out := strings.Join(pos, ",")
+		if out != test.pos || s != test.s || b != test.b {
+			failTestCase(t, test.args, []any{out, s, b}, []any{test.pos, test.s, test.b})
+		}
+	}
+
+	_, _, _, err := cli.ParseArgs([]string{"name", "-unknown"})
+	if err == nil {
+		failTestCase(t, "-unknown", err, "error")
+	}
+}
+
+func TestLegacyArgs(t *testing.T) {
+	t.Parallel()
+
+	type test struct {
+		args []string
+		want string
+	}
+
+	tests := []test{
+		{[]string{"-change"}, "passwd"},
+		{[]string{"-list"}, "ls"},
+		{[]string{"-list-all"}, "ls -all"},
+		{[]string{"-clear"}, "clear"},
+		{[]string{"-fix-permissions"}, "fix-permissions"},
+		{[]string{"-get", "hi"}, "get -- hi"},
+		{[]string{"-get", "-hi"}, "get -- -hi"},
+		{[]string{"-delete", "hi"}, "rm -- hi"},
+		{[]string{"-generate", "12"}, "gen -length 12"},
+		{
+			[]string{"-put", "hi", "-username", "bob", "-field", "b=2", "-field", "a=1"},
+			"put -username bob -url  -notes  -tags  -field a=1 -field b=2 -- hi",
+		},
+		{
+			[]string{"-put", "hi", "-generate", "12"},
+			"put -username  -url  -notes  -tags  -generate 12 -- hi",
+		},
+	}
+
+	for _, test := range tests {
+		t.Log(test)
+
+		cmd, err := cli.LegacyArgs(test.args)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		out := strings.Join(cmd, " ")
+		if out != test.want {
+			failTestCase(t, test.args, out, test.want)
+		}
+	}
+
+	for _, args := range [][]string{{}, {"-get", "a", "-list"}, {"-change", "-clear"}, {"-list", "name"}, {"-unknown"}} {
+		t.Log(args)
+
+		_, err := cli.LegacyArgs(args)
+		if !errors.Is(err, cli.ErrUsage) {
+			failTestCase(t, args, err, cli.ErrUsage)
+		}
+	}
+}
+
+func TestPolicy(t *testing.T) {
+	t.Parallel()
+
+	type test struct {
+		args []string
+		// want are the classes of the policy as chars=min separated by spaces, and the excluded characters.
+		want    string
+		exclude string
+	}
+
+	all := crypto.Lower + "=1 " + crypto.Upper + "=1 " + crypto.Digits + "=1 " + crypto.Symbols + "=1"
+
+	tests := []test{
+		{nil, all, ""},
+		{[]string{"-classes", "lower,digits"}, crypto.Lower + "=1 " + crypto.Digits + "=1", ""},
+		{[]string{"-classes", "digits,digits"}, crypto.Digits + "=1", ""},
+		{[]string{"-classes", "lower,digits", "-min", "digits=3"}, crypto.Lower + "=1 " + crypto.Digits + "=3", ""},
+		{[]string{"-min", "upper=0", "-min", "symbols=2"}, crypto.Lower + "=1 " + crypto.Upper + "=0 " + crypto.Digits + "=1 " + crypto.Symbols + "=2", ""},
+		{[]string{"-alphabet", "abc"}, "abc=0", ""},
+		{[]string{"-exclude", "xyz", "-no-ambiguous", "-alphabet", "abc"}, "abc=0", "xyz" + crypto.Ambiguous},
+	}
+
+	for _, test := range tests {
+		t.Log(test)
+
+		p, err := cli.Policy(test.args, 20)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		temp := make([]string, 0, len(p.Classes))
+		for _, c := range p.Classes {
+			temp = append(temp, fmt.Sprintf("%s=%d", c.Chars, c.Min))
+		}
+
+		out := strings.Join(temp, " ")
+		if out != test.want || p.Exclude != test.exclude || p.Length != 20 {
+			failTestCase(t, test.args, []any{out, p.Exclude, p.Length}, []any{test.want, test.exclude, 20})
+		}
+	}
+
+	errTests := [][]string{
+		{"-classes", "lower,emoji"},
+		{"-classes", "lower", "-min", "digits=2"},
+		{"-alphabet", "abc", "-min", "lower=2"},
+	}
+
+	for _, args := range errTests {
+		t.Log(args)
+
+		_, err := cli.Policy(args, 20)
+		if !errors.Is(err, cli.ErrUsage) {
+			failTestCase(t, args, err, cli.ErrUsage)
+		}
+	}
+
+	for _, args := range [][]string{{"-min", "emoji=2"}, {"-min", "digits=-1"}, {"-min", "digits"}} {
+		t.Log(args)
+
+		_, err := cli.Policy(args, 20)
+		if err == nil {
+			failTestCase(t, args, err, "error")
+		}
+	}
+}
+
+func TestEntryJSON(t *testing.T) {
+	t.Parallel()
+
+	const secret = "JBSWY3DPEHPK3PXP"
+
+	type test struct {
+		entry passwdstore.Entry
+		want  []string
+	}
+
+	tests := []test{
+		{passwdstore.Entry{Password: "hunter2"}, []string{`"password":"hunter2"`}},
+		{passwdstore.Entry{Password: "hunter2", OTP: "otpauth://hotp/x?secret=" + secret + "&counter=1"}, []string{`"otp":true`}},
+		{passwdstore.Entry{Password: "hunter2", OTP: secret, Username: "me"}, []string{`"otp":true`, `"username":"me"`}},
+		{passwdstore.Entry{Password: "hunter2", History: []passwdstore.Version{{Password: secret}, {Password: secret}}}, []string{`"history":2`}},
+	}
+
+	for _, test := range tests {
+		t.Log(test)
+
+		b, err := cli.EntryJSON("hi", test.entry)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		out := string(b)
+		for _, w := range test.want {
+			if !strings.Contains(out, w) {
+				failTestCase(t, test.entry, out, w)
+			}
+		}
+
+		if strings.Contains(out, secret) {
+			failTestCase(t, test.entry, out, "no otp key or previous passwords")
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/231tr0n/vault/internal/clipboard"
+)
+
+// defaultClipTimeout is the default time after which the clipboard is cleared.
+const defaultClipTimeout = 45 * time.Second
+
+// clipFlags holds the flags of a command which copies secrets to the clipboard.
+type clipFlags struct {
+	clip    bool
+	timeout time.Duration
+}
+
+// addClipFlags adds the -clip and -clip-timeout flags to the command.
+func addClipFlags(c *command) *clipFlags {
+	f := &clipFlags{}
+	c.fs.BoolVar(&f.clip, "clip", false, "Copies the password to the clipboard instead of printing it. "+
+		"The clipboard backend is detected or set with the "+clipboard.BackendEnv+" environment variable.")
+	c.fs.DurationVar(&f.timeout, "clip-timeout", defaultClipTimeout, "Clears the clipboard after the time given if it still has the password. "+
+		"0 keeps it in the clipboard.")
+
+	return f
+}
+
+// detectClipboard returns the clipboard backend the secrets are copied to.
+func detectClipboard() (clipboard.Backend, error) {
+	backend, err := clipboard.Detect()
+
+	return backend, wrap(err)
+}
+
+// copy copies "b" to the clipboard of the backend and waits till the timeout is over to clear it.
+// An interrupt clears the clipboard right away.
+func (f *clipFlags) copy(backend clipboard.Backend, b []byte, decorated bool) error {
+	if f.timeout < 0 {
+		return fmt.Errorf("%w: -clip-timeout must not be negative", ErrUsage)
+	}
+
+	err := backend.Copy(b)
+	if err != nil {
+		return wrap(err)
+	}
+
+	if decorated {
+		//nolint
+		fmt.Println("-----------------")
+		//nolint
+		fmt.Println("Password copied to the clipboard")
+	}
+
+	if f.timeout == 0 {
+		return nil
+	}
+
+	fmt.Fprintf(os.Stderr, "Clearing the clipboard in %s, press Ctrl-C to clear it now\n", f.timeout)
+
+	ctx, stop := signal.NotifyContext(context.Background(), clearSignals...)
+	defer stop()
+
+	return wrap(clipboard.ClearAfter(ctx, backend, b, f.timeout))
+}
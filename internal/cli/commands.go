@@ -0,0 +1,807 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/231tr0n/vault/pkg/crypto"
+	"github.com/231tr0n/vault/pkg/otp"
+	"github.com/231tr0n/vault/pkg/passwdstore"
+)
+
+const (
+	// defaultGenerateLength is the default length of generated passwords.
+	defaultGenerateLength = 20
+)
+
+// fieldsFlag is a flag which can be given multiple times to set custom fields of the form name=value.
+type fieldsFlag map[string]string
+
+func (f fieldsFlag) String() string {
+	temp := make([]string, 0, len(f))
+	for k, v := range f {
+		temp = append(temp, k+"="+v)
+	}
+
+	return strings.Join(temp, ",")
+}
+
+func (f fieldsFlag) Set(s string) error {
+	k, v, ok := strings.Cut(s, "=")
+	if !ok || k == "" {
+		return errInvalidField
+	}
+
+	f[k] = v
+
+	return nil
+}
+
+// splitList splits a comma separated flag value, dropping spaces around the items and empty items.
+func splitList(s string) []string {
+	var temp []string
+
+	for _, i := range strings.Split(s, ",") {
+		i = strings.TrimSpace(i)
+		if i != "" {
+			temp = append(temp, i)
+		}
+	}
+
+	return temp
+}
+
+// overwriteHint adds a hint to use -force to the error if it is passwdstore.ErrEntryExists.
+func overwriteHint(err error) error {
+	if !errors.Is(err, passwdstore.ErrEntryExists) {
+		return err
+	}
+
+	return fmt.Errorf("%w, give -force to overwrite it", err)
+}
+
+// suggest adds "did you mean" suggestions to the error if it is passwdstore.ErrEntryNotFound.
+func suggest(s *passwdstore.Session, k string, err error) error {
+	if !errors.Is(err, passwdstore.ErrEntryNotFound) {
+		return err
+	}
+
+	keys, kerr := s.ListKeys()
+	if kerr != nil {
+		return err
+	}
+
+	suggestions := passwdstore.Suggest(k, keys)
+	if len(suggestions) == 0 {
+		return err
+	}
+
+	return fmt.Errorf("%w, did you mean %s?", err, strings.Join(suggestions, ", "))
+}
+
+func getCommand() *command {
+	c := newCommand("get", "name", "Gets the password from the vault.")
+	c.minArgs, c.maxArgs = 1, 1
+	toFile := c.fs.String("to-file", "", "Writes the password or binary value to the file with permissions 0600 instead of printing it.")
+	clip := addClipFlags(c)
+	addOutputFlags(c)
+	addPasswdFlags(c)
+
+	c.run = func(args []string) error {
+		if *toFile != "" && clip.clip {
+			return fmt.Errorf("%w: only one of the flags -to-file and -clip can be given", ErrUsage)
+		}
+
+		pwd, err := c.passwd.read("Enter vault password: ")
+		if err != nil {
+			return err
+		}
+
+		s, err := vault.Unlock(pwd)
+		if err != nil {
+			return wrap(err)
+		}
+		defer s.Close()
+
+		e, err := s.GetEntry(args[0])
+		if err != nil {
+			return wrap(suggest(s, args[0], err))
+		}
+
+		if clip.clip {
+			// the vault is not needed while waiting to clear the clipboard.
+			s.Close()
+
+			backend, err := detectClipboard()
+			if err != nil {
+				return err
+			}
+
+			return clip.copy(backend, e.Value(), c.out.decorated())
+		}
+
+		if *toFile != "" {
+			err = writeSecretFile(*toFile, e.Value())
+			if err != nil {
+				return err
+			}
+
+			if c.out.decorated() {
+				//nolint
+				fmt.Println("-----------------")
+				//nolint
+				fmt.Println("Password written to", *toFile)
+			}
+
+			return nil
+		}
+
+		return c.out.printEntry(args[0], e)
+	}
+
+	return c
+}
+
+func putCommand() *command {
+	c := newCommand("put", "name", "Puts the password in the vault.")
+	c.minArgs, c.maxArgs = 1, 1
+	username := c.fs.String("username", "", "Sets the username of the password.")
+	url := c.fs.String("url", "", "Sets the url of the password.")
+	notes := c.fs.String("notes", "", "Sets the notes of the password.")
+	tags := c.fs.String("tags", "", "Sets the comma separated tags of the password.")
+	fields := fieldsFlag{}
+	c.fs.Var(fields, "field", "Sets a custom field of the form name=value of the password. Can be given multiple times.")
+	generate := c.fs.Int("generate", 0, "Generates a random password of the length given and stores that in the vault.")
+	policy := addPolicyFlags(c)
+	minScore := addMinScoreFlag(c, "Warns if the password is weaker than the minimum strength score.")
+	fromFile := c.fs.String("from-file", "", "Stores the contents of the file as a binary value, for certificates, keys and other multi-line secrets.")
+	otpKey := c.fs.String("otp", "", "Sets the key of the one-time codes of the entry, an otpauth:// uri or a base32 secret. "+
+		"The password of an existing entry is kept unless a new one is given with -generate, -from-file or -stdin.")
+	fromStdin := c.fs.Bool("stdin", false, "Stores the rest of stdin as a binary value. "+
+		"The vault password is read from the first line of stdin unless given by another source.")
+	addPasswdFlags(c)
+
+	c.run = func(args []string) error {
+		name := args[0]
+
+		sources := 0
+
+		for _, ok := range []bool{*generate > 0, *fromFile != "", *fromStdin} {
+			if ok {
+				sources++
+			}
+		}
+
+		if sources > 1 {
+			return fmt.Errorf("%w: only one of the flags -generate, -from-file and -stdin can be given", ErrUsage)
+		}
+
+		if *otpKey != "" {
+			_, err := otp.Parse(strings.TrimSpace(*otpKey))
+			if err != nil {
+				return fmt.Errorf("%w: %s", ErrUsage, err.Error())
+			}
+		}
+
+		err := checkMinScore(*minScore)
+		if err != nil {
+			return err
+		}
+
+		pwd, err := c.passwd.read("Enter vault password: ")
+		if err != nil {
+			return err
+		}
+
+		// only the otp key of an existing entry is changed when -otp is given without a value.
+		otpOnly := *otpKey != "" && sources == 0
+
+		if otpOnly {
+			_, err = vault.GetEntry(name, pwd)
+			if err != nil && !errors.Is(err, passwdstore.ErrEntryNotFound) {
+				return wrap(err)
+			}
+
+			otpOnly = err == nil
+		}
+
+		// the value is read before the vault is locked, so that other processes do not wait for the prompt.
+		var value []byte
+
+		switch {
+		case otpOnly:
+		case *generate > 0:
+			value, err = policy.generate(*generate)
+			if err != nil {
+				return err
+			}
+			//nolint
+			fmt.Println("-----------------")
+			//nolint
+			fmt.Println("Generated password:", string(value))
+		case *fromFile != "":
+			value, err = os.ReadFile(*fromFile)
+			if err != nil {
+				return wrap(err)
+			}
+		case *fromStdin:
+			value, err = readAllInput("Enter value for '" + name + "', end with Ctrl-D:\n")
+			if err != nil {
+				return err
+			}
+		default:
+			value, err = readSecureInput("Enter password for '" + name + "': ")
+			if err != nil {
+				return err
+			}
+		}
+
+		err = vault.Update(pwd, func(s *passwdstore.Session) error {
+			e, err := s.GetEntry(name)
+			if err != nil && (otpOnly || !errors.Is(err, passwdstore.ErrEntryNotFound)) {
+				return err
+			}
+
+			switch {
+			case otpOnly:
+			case *fromFile != "" || *fromStdin:
+				e.Password, e.Data = "", value
+			default:
+				e.Password, e.Data = string(value), nil
+			}
+
+			if !otpOnly && *generate == 0 && e.Data == nil {
+				weakness, err := checkStrength(value, *minScore, name, e.Username, *username)
+				if err != nil {
+					return err
+				}
+
+				if weakness != "" {
+					fmt.Fprintf(os.Stderr, "Warning: the password for '%s' is weak, %s\n", name, weakness)
+				}
+			}
+
+			if *otpKey != "" {
+				e.OTP = strings.TrimSpace(*otpKey)
+			}
+
+			if *username != "" {
+				e.Username = *username
+			}
+
+			if *url != "" {
+				e.URL = *url
+			}
+
+			if *notes != "" {
+				e.Notes = *notes
+			}
+
+			if *tags != "" {
+				e.Tags = splitList(*tags)
+			}
+
+			if len(fields) > 0 && e.Fields == nil {
+				e.Fields = make(map[string]string, len(fields))
+			}
+
+			for k, v := range fields {
+				e.Fields[k] = v
+			}
+
+			return s.PutEntry(name, e)
+		})
+		if err != nil {
+			return wrap(err)
+		}
+
+		//nolint
+		fmt.Println("-----------------")
+		//nolint
+		fmt.Println("Password stored")
+
+		return nil
+	}
+
+	return c
+}
+
+func rmCommand() *command {
+	c := newCommand("rm", "name", "Deletes the password in the vault.")
+	c.minArgs, c.maxArgs = 1, 1
+	c.aliases = []string{"delete"}
+	recursive := c.fs.Bool("r", false, "Deletes the folder and all the passwords in it.")
+	addPasswdFlags(c)
+
+	c.run = func(args []string) error {
+		pwd, err := c.passwd.read("Enter vault password: ")
+		if err != nil {
+			return err
+		}
+
+		n := 1
+
+		err = vault.Update(pwd, func(s *passwdstore.Session) error {
+			var err error
+			if *recursive {
+				n, err = s.DeleteTree(args[0])
+			} else {
+				err = s.Delete(args[0])
+			}
+
+			return suggest(s, args[0], err)
+		})
+		if err != nil {
+			return wrap(err)
+		}
+
+		//nolint
+		fmt.Println("-----------------")
+
+		if *recursive {
+			//nolint
+			fmt.Println(n, "passwords deleted")
+		} else {
+			//nolint
+			fmt.Println("Password deleted")
+		}
+
+		return nil
+	}
+
+	return c
+}
+
+func lsCommand() *command {
+	c := newCommand("ls", "[folder]", "Lists all the password names in the vault, or only the ones in the folder.")
+	c.maxArgs = 1
+	c.aliases = []string{"list"}
+	all := c.fs.Bool("all", false, "Lists all the passwords with names(dangerous).")
+	addOutputFlags(c)
+	addPasswdFlags(c)
+
+	c.run = func(args []string) error {
+		pwd, err := c.passwd.read("Enter vault password: ")
+		if err != nil {
+			return err
+		}
+
+		folder := ""
+		if len(args) > 0 {
+			folder = args[0]
+		}
+
+		if *all {
+			list, err := vault.ListEntriesWithPrefix(folder, pwd)
+			if err != nil {
+				return wrap(err)
+			}
+
+			return c.out.printEntries(list)
+		}
+
+		list, err := vault.ListKeysWithPrefix(folder, pwd)
+		if err != nil {
+			return wrap(err)
+		}
+
+		return c.out.printKeys(list)
+	}
+
+	return c
+}
+
+func mvCommand() *command {
+	c := newCommand("mv", "from to", "Moves the password or the folder with all the passwords in it to a new name.")
+	c.minArgs, c.maxArgs = 2, 2
+	c.aliases = []string{"rename"}
+	force := c.fs.Bool("force", false, "Overwrites the passwords which already exist with the new names.")
+	addPasswdFlags(c)
+
+	c.run = func(args []string) error {
+		pwd, err := c.passwd.read("Enter vault password: ")
+		if err != nil {
+			return err
+		}
+
+		n, err := vault.MoveTree(args[0], args[1], *force, pwd)
+		if err != nil {
+			return wrap(overwriteHint(err))
+		}
+
+		//nolint
+		fmt.Println("-----------------")
+		//nolint
+		fmt.Println(n, "passwords moved")
+
+		return nil
+	}
+
+	return c
+}
+
+func cpCommand() *command {
+	c := newCommand("cp", "from to", "Copies the password or the folder with all the passwords in it to a new name.")
+	c.minArgs, c.maxArgs = 2, 2
+	c.aliases = []string{"copy"}
+	force := c.fs.Bool("force", false, "Overwrites the passwords which already exist with the new names.")
+	addPasswdFlags(c)
+
+	c.run = func(args []string) error {
+		pwd, err := c.passwd.read("Enter vault password: ")
+		if err != nil {
+			return err
+		}
+
+		n, err := vault.CopyTree(args[0], args[1], *force, pwd)
+		if err != nil {
+			return wrap(overwriteHint(err))
+		}
+
+		//nolint
+		fmt.Println("-----------------")
+		//nolint
+		fmt.Println(n, "passwords copied")
+
+		return nil
+	}
+
+	return c
+}
+
+func searchCommand() *command {
+	c := newCommand("search", "[pattern]", "Searches the names, usernames, urls, tags and notes of the passwords in the vault. "+
+		"The passwords are not printed unless -show is given.")
+	c.maxArgs = 1
+	c.aliases = []string{"find"}
+	glob := c.fs.Bool("glob", false, "Matches whole fields with a glob pattern of *, ? and [...] instead of a substring.")
+	re := c.fs.Bool("regexp", false, "Matches fields with a regular expression instead of a substring.")
+	fields := c.fs.String("fields", "", "Searches only the comma separated fields, of "+strings.Join(passwdstore.SearchFields, ", ")+".")
+	tags := c.fs.String("tags", "", "Finds only the passwords with all the comma separated tags.")
+	folder := c.fs.String("folder", "", "Finds only the passwords in the folder.")
+	show := c.fs.Bool("show", false, "Prints the passwords of the results too(dangerous).")
+	addOutputFlags(c)
+	addPasswdFlags(c)
+
+	c.run = func(args []string) error {
+		q := passwdstore.Query{
+			Fields: splitList(*fields),
+			Tags:   splitList(*tags),
+			Folder: *folder,
+		}
+
+		if len(args) > 0 {
+			q.Pattern = args[0]
+		}
+
+		switch {
+		case *glob && *re:
+			return fmt.Errorf("%w: only one of the flags -glob and -regexp can be given", ErrUsage)
+		case *glob:
+			q.Mode = passwdstore.MatchGlob
+		case *re:
+			q.Mode = passwdstore.MatchRegexp
+		}
+
+		if q.Pattern == "" && len(q.Tags) == 0 && q.Folder == "" {
+			return fmt.Errorf("%w: search needs a pattern, -tags or -folder", ErrUsage)
+		}
+
+		pwd, err := c.passwd.read("Enter vault password: ")
+		if err != nil {
+			return err
+		}
+
+		results, err := vault.Search(q, pwd)
+		if err != nil {
+			return wrap(err)
+		}
+
+		return c.out.printResults(results, *show)
+	}
+
+	return c
+}
+
+func historyCommand() *command {
+	c := newCommand("history", "name", "Prints the previous passwords of the entry, the most recent first.")
+	c.minArgs, c.maxArgs = 1, 1
+	addOutputFlags(c)
+	addPasswdFlags(c)
+
+	c.run = func(args []string) error {
+		pwd, err := c.passwd.read("Enter vault password: ")
+		if err != nil {
+			return err
+		}
+
+		s, err := vault.Unlock(pwd)
+		if err != nil {
+			return wrap(err)
+		}
+		defer s.Close()
+
+		e, err := s.GetEntry(args[0])
+		if err != nil {
+			return wrap(suggest(s, args[0], err))
+		}
+
+		return c.out.printHistory(e.History)
+	}
+
+	return c
+}
+
+func restoreCommand() *command {
+	c := newCommand("restore", "name", "Restores a previous password of the entry. The current password is kept in the history.")
+	c.minArgs, c.maxArgs = 1, 1
+	version := c.fs.Int("version", 1, "Restores the version of the password shown by 'vault history', 1 is the most recent previous password.")
+	addPasswdFlags(c)
+
+	c.run = func(args []string) error {
+		pwd, err := c.passwd.read("Enter vault password: ")
+		if err != nil {
+			return err
+		}
+
+		err = vault.Update(pwd, func(s *passwdstore.Session) error {
+			return suggest(s, args[0], s.Restore(args[0], *version))
+		})
+		if err != nil {
+			return wrap(err)
+		}
+
+		//nolint
+		fmt.Println("-----------------")
+		//nolint
+		fmt.Println("Password restored")
+
+		return nil
+	}
+
+	return c
+}
+
+func passwdCommand() *command {
+	c := newCommand("passwd", "", "Changes the vault password. Give an empty old password to set it initially.")
+	minScore := addMinScoreFlag(c, "Minimum strength score of the new vault password.")
+	force := c.fs.Bool("force", false, "Sets the new vault password even if it is weaker than the minimum score.")
+	addPasswdFlags(c)
+
+	c.run = func(_ []string) error {
+		oldPwd, err := c.passwd.read("Enter old vault password: ")
+		if err != nil {
+			return err
+		}
+
+		newPwd, err := readSecureInput("Enter new vault password: ")
+		if err != nil {
+			return err
+		}
+
+		newPwdCheck, err := readSecureInput("Re-Enter new vault password: ")
+		if err != nil {
+			return err
+		}
+
+		if string(newPwd) != string(newPwdCheck) {
+			return errPasswdMismatch
+		}
+
+		weakness, err := checkStrength(newPwd, *minScore, "vault")
+		if err != nil {
+			return err
+		}
+
+		if weakness != "" && !*force {
+			return fmt.Errorf("%w: %s", errWeakPasswd, weakness)
+		}
+
+		if weakness != "" {
+			fmt.Fprintf(os.Stderr, "Warning: the new vault password is weak, %s\n", weakness)
+		}
+
+		err = vault.ChangePasswd(newPwd, oldPwd)
+		if err != nil {
+			return wrap(err)
+		}
+
+		//nolint
+		fmt.Println("-----------------")
+		//nolint
+		fmt.Println("Vault password changed")
+
+		return nil
+	}
+
+	return c
+}
+
+func genCommand() *command {
+	c := newCommand("gen", "", "Generates a new random password or passphrase.")
+	c.aliases = []string{"generate"}
+	length := c.fs.Int("length", defaultGenerateLength, "Length of the generated password.")
+	policy := addPolicyFlags(c)
+	words := c.fs.Int("words", 0, "Generates a passphrase of the number of words given from the EFF large wordlist instead of a password. "+
+		"The flags of the password are ignored.")
+	separator := c.fs.String("separator", "-", "Separator of the words of the passphrase.")
+	capitalize := c.fs.Bool("capitalize", false, "Capitalizes the first letter of each word of the passphrase.")
+	digit := c.fs.Bool("digit", false, "Inserts a random digit in the passphrase.")
+	symbol := c.fs.Bool("symbol", false, "Inserts a random symbol in the passphrase.")
+	clip := addClipFlags(c)
+	addOutputFlags(c)
+
+	c.run = func(_ []string) error {
+		if *words < 0 {
+			return fmt.Errorf("%w: number of words must be positive", ErrUsage)
+		}
+
+		if *words > 0 {
+			p := crypto.PassphrasePolicy{
+				Words:      *words,
+				Separator:  *separator,
+				Capitalize: *capitalize,
+				Digit:      *digit,
+				Symbol:     *symbol,
+			}
+
+			pwd, err := p.Generate()
+			if err != nil {
+				return wrap(err)
+			}
+
+			if clip.clip {
+				backend, err := detectClipboard()
+				if err != nil {
+					return err
+				}
+
+				return clip.copy(backend, pwd, c.out.decorated())
+			}
+
+			return c.out.printGenerated(string(pwd), p.Entropy())
+		}
+
+		if *length <= 0 {
+			return fmt.Errorf("%w: length must be positive", ErrUsage)
+		}
+
+		pwd, err := policy.generate(*length)
+		if err != nil {
+			return err
+		}
+
+		if clip.clip {
+			backend, err := detectClipboard()
+			if err != nil {
+				return err
+			}
+
+			return clip.copy(backend, pwd, c.out.decorated())
+		}
+
+		return c.out.printGenerated(string(pwd), 0)
+	}
+
+	return c
+}
+
+func otpCommand() *command {
+	c := newCommand("otp", "name", "Prints the current one-time code of the entry and the seconds it is valid for.")
+	c.minArgs, c.maxArgs = 1, 1
+	clip := addClipFlags(c)
+	addOutputFlags(c)
+	addPasswdFlags(c)
+
+	c.run = func(args []string) error {
+		pwd, err := c.passwd.read("Enter vault password: ")
+		if err != nil {
+			return err
+		}
+
+		var (
+			hasOTP    bool
+			code      string
+			remaining time.Duration
+		)
+
+		// the counter of hotp keys is incremented under the lock, so that concurrent calls never get the same code.
+		err = vault.Update(pwd, func(s *passwdstore.Session) error {
+			e, err := s.GetEntry(args[0])
+			if err != nil {
+				return suggest(s, args[0], err)
+			}
+
+			hasOTP = e.OTP != ""
+			if !hasOTP {
+				return nil
+			}
+
+			k, err := otp.Parse(e.OTP)
+			if err != nil {
+				return err
+			}
+
+			code, remaining, err = k.Code(time.Now())
+			if err != nil {
+				return err
+			}
+
+			if k.Type != otp.TypeHOTP {
+				return nil
+			}
+
+			k.Counter++
+			e.OTP = k.URI()
+
+			return s.PutEntry(args[0], e)
+		})
+		if err != nil {
+			return wrap(err)
+		}
+
+		if !hasOTP {
+			return fmt.Errorf("%w: %s", errNoOTP, args[0])
+		}
+
+		if clip.clip {
+			backend, err := detectClipboard()
+			if err != nil {
+				return err
+			}
+
+			return clip.copy(backend, []byte(code), c.out.decorated())
+		}
+
+		return c.out.printOTP(code, remaining)
+	}
+
+	return c
+}
+
+func clearCommand() *command {
+	c := newCommand("clear", "", "Clears all the passwords in the vault.")
+	addPasswdFlags(c)
+
+	c.run = func(_ []string) error {
+		pwd, err := c.passwd.read("Enter vault password: ")
+		if err != nil {
+			return err
+		}
+
+		err = vault.Clear(pwd)
+		if err != nil {
+			return wrap(err)
+		}
+
+		//nolint
+		fmt.Println("-----------------")
+		//nolint
+		fmt.Println("Vault cleared")
+
+		return nil
+	}
+
+	return c
+}
+
+func fixPermissionsCommand() *command {
+	c := newCommand("fix-permissions", "", "Restricts the permissions of the vault file and its directory to the current user.")
+
+	c.run = func(_ []string) error {
+		err := vault.FixPermissions()
+		if err != nil {
+			return wrap(err)
+		}
+
+		//nolint
+		fmt.Println("Vault permissions fixed")
+
+		return nil
+	}
+
+	return c
+}
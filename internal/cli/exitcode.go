@@ -0,0 +1,64 @@
+package cli
+
+import (
+	"errors"
+
+	"github.com/231tr0n/vault/pkg/crypto"
+	"github.com/231tr0n/vault/pkg/passwdstore"
+)
+
+// Exit codes of the vault command. They are documented in cmd/vault/README.md.
+const (
+	// ExitOK is returned when the command succeeds.
+	ExitOK = 0
+	// ExitError is returned for errors which do not have their own exit code.
+	ExitError = 1
+	// ExitUsage is returned when the arguments are wrong.
+	ExitUsage = 2
+	// ExitWrongPasswd is returned when the vault password is wrong.
+	ExitWrongPasswd = 3
+	// ExitIntegrityFail is returned when the vault file is tampered, corrupt or of an unsupported version.
+	ExitIntegrityFail = 4
+	// ExitNotFound is returned when the entry is not found.
+	ExitNotFound = 5
+	// ExitLocked is returned when the vault is locked or modified by another process.
+	ExitLocked = 6
+	// ExitInsecurePermissions is returned when the vault file can be accessed by other users.
+	ExitInsecurePermissions = 7
+	// ExitPasswdNotSet is returned when the vault password is not set yet.
+	ExitPasswdNotSet = 8
+	// ExitExists is returned when an entry would be overwritten without -force.
+	ExitExists = 9
+)
+
+// ErrUsage is the error thrown when the command line arguments are wrong.
+var ErrUsage = errors.New("cli: wrong usage, run 'vault help' to get the usage")
+
+// ExitCode returns the exit code for the error returned by Init or Parse.
+func ExitCode(err error) int {
+	switch {
+	case err == nil:
+		return ExitOK
+	case errors.Is(err, ErrUsage), errors.Is(err, crypto.ErrInvalidPolicy), errors.Is(err, passwdstore.ErrInvalidKey),
+		errors.Is(err, passwdstore.ErrInvalidPattern), errors.Is(err, passwdstore.ErrUnknownField):
+		return ExitUsage
+	case errors.Is(err, crypto.ErrWrongPasswd):
+		return ExitWrongPasswd
+	case errors.Is(err, passwdstore.ErrPasswdFileIntegrityFail),
+		errors.Is(err, passwdstore.ErrPasswdFileManuallyEdited),
+		errors.Is(err, passwdstore.ErrUnsupportedFileVersion), errors.Is(err, crypto.ErrInvalidKDFParams):
+		return ExitIntegrityFail
+	case errors.Is(err, passwdstore.ErrEntryNotFound), errors.Is(err, passwdstore.ErrVersionNotFound):
+		return ExitNotFound
+	case errors.Is(err, passwdstore.ErrVaultLocked), errors.Is(err, passwdstore.ErrVaultModified):
+		return ExitLocked
+	case errors.Is(err, passwdstore.ErrInsecurePermissions):
+		return ExitInsecurePermissions
+	case errors.Is(err, passwdstore.ErrVaultPasswdNotSet):
+		return ExitPasswdNotSet
+	case errors.Is(err, passwdstore.ErrEntryExists):
+		return ExitExists
+	default:
+		return ExitError
+	}
+}
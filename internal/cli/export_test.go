@@ -0,0 +1,56 @@
+package cli
+
+import (
+	"encoding/json"
+	"flag"
+	"io"
+	"time"
+
+	"github.com/231tr0n/vault/internal/clipboard"
+	"github.com/231tr0n/vault/pkg/crypto"
+	"github.com/231tr0n/vault/pkg/passwdstore"
+)
+
+// CopyToClipboard copies "b" to the backend like the -clip flag with the -clip-timeout "timeout".
+func CopyToClipboard(backend clipboard.Backend, b []byte, timeout time.Duration) error {
+	f := &clipFlags{clip: true, timeout: timeout}
+
+	return f.copy(backend, b, false)
+}
+
+// ParseArgs parses "args" with a flag set of the string flag -s and the bool flag -b
+// and returns the positional arguments along with the values of the flags.
+func ParseArgs(args []string) ([]string, string, bool, error) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	s := fs.String("s", "", "")
+	b := fs.Bool("b", false, "")
+
+	pos, err := parseArgs(fs, args)
+
+	return pos, *s, *b, err
+}
+
+// LegacyArgs returns the subcommand with its arguments which the flags of the older versions are run as.
+func LegacyArgs(args []string) ([]string, error) {
+	return legacyArgs(args)
+}
+
+// Policy returns the password policy of length "l" which the password generator flags in "args" describe.
+func Policy(args []string, l int) (crypto.Policy, error) {
+	c := newCommand("test", "", "")
+	c.fs.SetOutput(io.Discard)
+	p := addPolicyFlags(c)
+
+	_, err := parseArgs(c.fs, args)
+	if err != nil {
+		return crypto.Policy{}, err
+	}
+
+	return p.policy(l)
+}
+
+// EntryJSON returns the json which get -format json prints for the entry with the name "k".
+func EntryJSON(k string, e passwdstore.Entry) ([]byte, error) {
+	return json.Marshal(newEntryOutput(k, e))
+}
@@ -0,0 +1,123 @@
+package cli
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"syscall"
+
+	"golang.org/x/term"
+)
+
+// passwdEnv is the environment variable the vault password is read from.
+const passwdEnv = "VAULT_PASSWORD"
+
+// errNoInput is the error thrown when there is no input left to read a secret from.
+var errNoInput = errors.New("cli: no input left to read the secret from")
+
+// stdinReader reads lines from stdin when it is not a terminal.
+// It is shared so that each secret read consumes the next line.
+var stdinReader *bufio.Reader
+
+// passwdSource holds the flags which select where the vault password is read from.
+type passwdSource struct {
+	fd   int
+	file string
+}
+
+// addPasswdFlags adds the flags selecting the source of the vault password to the command.
+func addPasswdFlags(c *command) {
+	c.passwd = &passwdSource{}
+	c.fs.IntVar(&c.passwd.fd, "password-fd", -1, "Reads the vault password from the first line of the open file descriptor. "+
+		"The password is only visible to the processes the descriptor is passed to.")
+	c.fs.StringVar(&c.passwd.file, "password-file", "", "Reads the vault password from the first line of the file. "+
+		"Anyone who can read the file can read the password, so restrict its permissions.")
+}
+
+// stdin returns the shared reader of stdin.
+func stdin() *bufio.Reader {
+	if stdinReader == nil {
+		stdinReader = bufio.NewReader(os.Stdin)
+	}
+
+	return stdinReader
+}
+
+// readLine reads a line from "r" without the line ending.
+func readLine(r *bufio.Reader) ([]byte, error) {
+	s, err := r.ReadString('\n')
+	if errors.Is(err, io.EOF) && s != "" {
+		err = nil
+	}
+
+	if errors.Is(err, io.EOF) {
+		return nil, errNoInput
+	}
+
+	if err != nil {
+		return nil, wrap(err)
+	}
+
+	return []byte(strings.TrimRight(s, "\r\n")), nil
+}
+
+// readSecureInput prompts with "c" on stderr, so that stdout only has the results, and reads the input without echoing it.
+// When stdin is not a terminal the next line of stdin is read without prompting.
+func readSecureInput(c string) ([]byte, error) {
+	if !term.IsTerminal(int(syscall.Stdin)) {
+		return readLine(stdin())
+	}
+
+	fmt.Fprint(os.Stderr, c)
+
+	s, err := term.ReadPassword(int(syscall.Stdin))
+
+	fmt.Fprintln(os.Stderr)
+
+	return s, wrap(err)
+}
+
+// readAllInput reads the rest of stdin as arbitrary bytes, prompting with "c" if stdin is a terminal.
+// The lines of stdin already read as secrets are not part of it.
+func readAllInput(c string) ([]byte, error) {
+	if term.IsTerminal(int(syscall.Stdin)) {
+		fmt.Fprint(os.Stderr, c)
+	}
+
+	b, err := io.ReadAll(stdin())
+
+	return b, wrap(err)
+}
+
+// read reads the vault password from the first source which is set in the order
+// -password-fd, -password-file, the VAULT_PASSWORD environment variable and
+// finally stdin, prompting with "c" if stdin is a terminal.
+func (p *passwdSource) read(c string) ([]byte, error) {
+	switch {
+	case p != nil && p.fd >= 0:
+		f := os.NewFile(uintptr(p.fd), "password-fd")
+		if f == nil {
+			return nil, fmt.Errorf("%w: invalid file descriptor %d", ErrUsage, p.fd)
+		}
+		defer f.Close()
+
+		return readLine(bufio.NewReader(f))
+	case p != nil && p.file != "":
+		f, err := os.Open(p.file)
+		if err != nil {
+			return nil, wrap(err)
+		}
+		defer f.Close()
+
+		return readLine(bufio.NewReader(f))
+	}
+
+	if pwd, ok := os.LookupEnv(passwdEnv); ok {
+		return []byte(pwd), nil
+	}
+
+	return readSecureInput(c)
+}
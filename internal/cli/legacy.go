@@ -0,0 +1,108 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+)
+
+// parseLegacy parses the flags of the older versions like "vault -get name" and runs the respective subcommand.
+func parseLegacy(args []string) error {
+	cmd, err := legacyArgs(args)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "vault: the flag syntax is deprecated, use 'vault %s' instead\n", cmd[0])
+
+	return runCommand(findCommand(cmd[0]), cmd[1:])
+}
+
+// legacyArgs returns the subcommand with its arguments which the flags of the older versions are run as.
+// Only one action flag can be given at a time.
+func legacyArgs(args []string) ([]string, error) {
+	fs := flag.NewFlagSet("vault", flag.ContinueOnError)
+	change := fs.Bool("change", false, "Changes the vault password. Deprecated, use 'vault passwd'.")
+	list := fs.Bool("list", false, "Lists all the password names in the vault. Deprecated, use 'vault ls'.")
+	listAll := fs.Bool("list-all", false, "Lists all the passwords with names(dangerous) in the vault. Deprecated, use 'vault ls -all'.")
+	clear := fs.Bool("clear", false, "Clears all the passwords in the vault. Deprecated, use 'vault clear'.")
+	fixPermissions := fs.Bool("fix-permissions", false, "Restricts the permissions of the vault file and its directory to the current user. Deprecated, use 'vault fix-permissions'.")
+	get := fs.String("get", "", "Gets the password from the vault. Deprecated, use 'vault get'.")
+	put := fs.String("put", "", "Puts the password in the vault. Deprecated, use 'vault put'.")
+	del := fs.String("delete", "", "Deletes the password in the vault. Deprecated, use 'vault rm'.")
+	username := fs.String("username", "", "Sets the username of the password when passed along with put flag.")
+	url := fs.String("url", "", "Sets the url of the password when passed along with put flag.")
+	notes := fs.String("notes", "", "Sets the notes of the password when passed along with put flag.")
+	tags := fs.String("tags", "", "Sets the comma separated tags of the password when passed along with put flag.")
+	fields := fieldsFlag{}
+	fs.Var(fields, "field", "Sets a custom field of the form name=value of the password when passed along with put flag. Can be given multiple times.")
+	//nolint
+	generate := fs.Int("generate", 0, "Generates a new random password of length given. If this flag is passed along with put flag, it generates a random password and stores that in the vault. Deprecated, use 'vault gen'.")
+
+	fs.Usage = func() {
+		usage(fs.Output())
+		fmt.Fprintf(fs.Output(), "\nDeprecated flags:\n")
+		fs.PrintDefaults()
+	}
+
+	err := fs.Parse(args)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrUsage, err.Error())
+	}
+
+	if fs.NArg() > 0 {
+		return nil, fmt.Errorf("%w: unexpected argument %q", ErrUsage, fs.Arg(0))
+	}
+
+	var cmd []string
+
+	actions := 0
+	action := func(ok bool, args ...string) {
+		if ok {
+			actions++
+			cmd = args
+		}
+	}
+
+	action(*change, "passwd")
+	action(*list, "ls")
+	action(*listAll, "ls", "-all")
+	action(*clear, "clear")
+	action(*fixPermissions, "fix-permissions")
+	action(*get != "", "get", "--", *get)
+	action(*del != "", "rm", "--", *del)
+	action(*generate > 0 && *put == "", "gen", "-length", strconv.Itoa(*generate))
+
+	if *put != "" {
+		putArgs := []string{"put", "-username", *username, "-url", *url, "-notes", *notes, "-tags", *tags}
+
+		names := make([]string, 0, len(fields))
+		for k := range fields {
+			names = append(names, k)
+		}
+
+		sort.Strings(names)
+
+		for _, k := range names {
+			putArgs = append(putArgs, "-field", k+"="+fields[k])
+		}
+
+		if *generate > 0 {
+			putArgs = append(putArgs, "-generate", strconv.Itoa(*generate))
+		}
+
+		action(true, append(putArgs, "--", *put)...)
+	}
+
+	switch actions {
+	case 0:
+		return nil, fmt.Errorf("%w: no arguments given", ErrUsage)
+	case 1:
+		return cmd, nil
+	default:
+		return nil, fmt.Errorf("%w: only one of the flags -change, -list, -list-all, -clear, -fix-permissions, "+
+			"-get, -put, -delete and -generate can be given", ErrUsage)
+	}
+}
@@ -0,0 +1,571 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/231tr0n/vault/pkg/passwdstore"
+)
+
+// Output formats of the commands which print results.
+const (
+	// formatText is the default human readable format decorated with banners.
+	formatText = "text"
+	// formatPlain prints one undecorated value per line.
+	formatPlain = "plain"
+	// formatTable prints undecorated tab aligned columns with a header row.
+	formatTable = "table"
+	// formatJSON prints a single json value.
+	formatJSON = "json"
+)
+
+// secretFilePerm is the permission of the files the values of entries are written to.
+const secretFilePerm = 0o600
+
+// output holds the output flags of a command and prints its results in the chosen format.
+type output struct {
+	format string
+	quiet  bool
+}
+
+// entryOutput is the json output of an entry.
+// The otp key is a secret of its own which is only reported as set and the previous values are only counted,
+// like in the text format. They are printed by vault otp and vault history.
+type entryOutput struct {
+	Name     string            `json:"name"`
+	Password string            `json:"password"`
+	Data     []byte            `json:"data,omitempty"`
+	Username string            `json:"username,omitempty"`
+	URL      string            `json:"url,omitempty"`
+	Notes    string            `json:"notes,omitempty"`
+	Tags     []string          `json:"tags,omitempty"`
+	Fields   map[string]string `json:"fields,omitempty"`
+	OTP      bool              `json:"otp,omitempty"`
+	History  int               `json:"history,omitempty"`
+	Created  time.Time         `json:"created"`
+	Modified time.Time         `json:"modified"`
+}
+
+// newEntryOutput returns the json output of the entry with the name "k".
+func newEntryOutput(k string, e passwdstore.Entry) entryOutput {
+	return entryOutput{
+		Name:     k,
+		Password: e.Password,
+		Data:     e.Data,
+		Username: e.Username,
+		URL:      e.URL,
+		Notes:    e.Notes,
+		Tags:     e.Tags,
+		Fields:   e.Fields,
+		OTP:      e.OTP != "",
+		History:  len(e.History),
+		Created:  e.Created,
+		Modified: e.Modified,
+	}
+}
+
+// listEntryOutput is the json output of an entry listed with its password.
+type listEntryOutput struct {
+	Name     string `json:"name"`
+	Password string `json:"password"`
+}
+
+// searchOutput is the json output of an entry found by a search.
+type searchOutput struct {
+	Name     string   `json:"name"`
+	Username string   `json:"username,omitempty"`
+	URL      string   `json:"url,omitempty"`
+	Tags     []string `json:"tags,omitempty"`
+	Matched  []string `json:"matched,omitempty"`
+	Password string   `json:"password,omitempty"`
+	Data     []byte   `json:"data,omitempty"`
+}
+
+// versionOutput is the json output of a previous value of an entry.
+type versionOutput struct {
+	Version  int       `json:"version"`
+	Password string    `json:"password"`
+	Data     []byte    `json:"data,omitempty"`
+	Replaced time.Time `json:"replaced"`
+}
+
+// otpOutput is the json output of a one-time code.
+type otpOutput struct {
+	Code string `json:"code"`
+	// Remaining is the number of seconds the code is valid for, 0 for counter based codes.
+	Remaining int `json:"remaining"`
+}
+
+// generatedOutput is the json output of a generated password.
+type generatedOutput struct {
+	Password string  `json:"password"`
+	Entropy  float64 `json:"entropy,omitempty"`
+}
+
+// addOutputFlags adds the -format and -quiet flags to the command.
+func addOutputFlags(c *command) {
+	c.out = &output{}
+	c.fs.StringVar(&c.out.format, "format", formatText, "Output format, one of text, plain, table or json. "+
+		"All formats except text are undecorated and stable for scripts.")
+	c.fs.BoolVar(&c.out.quiet, "quiet", false, "Prints only the secret or names without any decoration, for piping.")
+}
+
+// validate checks the output flags.
+func (o *output) validate() error {
+	switch o.format {
+	case formatText, formatPlain, formatTable, formatJSON:
+		return nil
+	default:
+		return fmt.Errorf("%w: unknown format %q", ErrUsage, o.format)
+	}
+}
+
+// decorated reports if banners and messages are printed around the results.
+func (o *output) decorated() bool {
+	return o == nil || (!o.quiet && o.format == formatText)
+}
+
+// printJSON prints "v" as indented json.
+func printJSON(v any) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+
+	return wrap(enc.Encode(v))
+}
+
+// printTable prints the rows as tab aligned columns.
+func printTable(rows ...[]string) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+
+	for _, row := range rows {
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+
+	return wrap(w.Flush())
+}
+
+// entryFields returns the non empty fields of the entry as name value pairs in a stable order.
+func entryFields(e passwdstore.Entry) [][2]string {
+	fields := [][2]string{{"Password", e.Password}}
+	if e.Data != nil {
+		fields[0] = [2]string{"Data", fmt.Sprintf("%d bytes, use -to-file to write it out", len(e.Data))}
+	}
+
+	if e.Username != "" {
+		fields = append(fields, [2]string{"Username", e.Username})
+	}
+
+	if e.URL != "" {
+		fields = append(fields, [2]string{"URL", e.URL})
+	}
+
+	if e.Notes != "" {
+		fields = append(fields, [2]string{"Notes", e.Notes})
+	}
+
+	if len(e.Tags) > 0 {
+		fields = append(fields, [2]string{"Tags", strings.Join(e.Tags, ",")})
+	}
+
+	if e.OTP != "" {
+		fields = append(fields, [2]string{"OTP", "set, use 'vault otp' to get the code"})
+	}
+
+	if len(e.History) > 0 {
+		fields = append(fields, [2]string{"History", fmt.Sprintf("%d previous values, use 'vault history' to see them", len(e.History))})
+	}
+
+	names := make([]string, 0, len(e.Fields))
+	for k := range e.Fields {
+		names = append(names, k)
+	}
+
+	sort.Strings(names)
+
+	for _, k := range names {
+		fields = append(fields, [2]string{k, e.Fields[k]})
+	}
+
+	if !e.Created.IsZero() {
+		fields = append(fields, [2]string{"Created", e.Created.Local().Format(time.RFC1123)})
+	}
+
+	if !e.Modified.IsZero() {
+		fields = append(fields, [2]string{"Modified", e.Modified.Local().Format(time.RFC1123)})
+	}
+
+	return fields
+}
+
+// printEntry prints the entry with the name "k".
+func (o *output) printEntry(k string, e passwdstore.Entry) error {
+	if o.quiet && e.Data != nil {
+		_, err := os.Stdout.Write(e.Data)
+
+		return wrap(err)
+	}
+
+	if o.quiet {
+		//nolint
+		fmt.Println(e.Password)
+
+		return nil
+	}
+
+	switch o.format {
+	case formatJSON:
+		return printJSON(newEntryOutput(k, e))
+	case formatTable:
+		rows := [][]string{{"FIELD", "VALUE"}}
+		for _, f := range entryFields(e) {
+			rows = append(rows, []string{f[0], f[1]})
+		}
+
+		return printTable(rows...)
+	case formatPlain:
+		for _, f := range entryFields(e) {
+			//nolint
+			fmt.Printf("%s\t%s\n", f[0], f[1])
+		}
+
+		return nil
+	default:
+		//nolint
+		fmt.Println("-----------------")
+
+		for _, f := range entryFields(e) {
+			//nolint
+			fmt.Println(f[0]+":", f[1])
+		}
+
+		return nil
+	}
+}
+
+// writeSecretFile writes the value "b" of an entry to the file "f" readable and writable only by the current user.
+// An existing file is truncated and its permissions are restricted.
+func writeSecretFile(f string, b []byte) error {
+	file, err := os.OpenFile(f, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, secretFilePerm)
+	if err != nil {
+		return wrap(err)
+	}
+
+	err = file.Chmod(secretFilePerm)
+	if err == nil {
+		_, err = file.Write(b)
+	}
+
+	cerr := file.Close()
+	if err == nil {
+		err = cerr
+	}
+
+	return wrap(err)
+}
+
+// printKeys prints the names of the entries sorted.
+func (o *output) printKeys(keys []string) error {
+	sort.Strings(keys)
+
+	if o.quiet {
+		for _, k := range keys {
+			//nolint
+			fmt.Println(k)
+		}
+
+		return nil
+	}
+
+	switch o.format {
+	case formatJSON:
+		return printJSON(keys)
+	case formatTable:
+		rows := [][]string{{"NAME"}}
+		for _, k := range keys {
+			rows = append(rows, []string{k})
+		}
+
+		return printTable(rows...)
+	case formatPlain:
+		for _, k := range keys {
+			//nolint
+			fmt.Println(k)
+		}
+
+		return nil
+	default:
+		//nolint
+		fmt.Println("-----------------")
+		//nolint
+		fmt.Println("List of passwords")
+		//nolint
+		fmt.Println("-----------------")
+
+		for i, k := range keys {
+			//nolint
+			fmt.Println(i, k)
+		}
+
+		return nil
+	}
+}
+
+// printEntries prints the names of the entries with their passwords sorted by name.
+func (o *output) printEntries(entries [][2]string) error {
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i][0] < entries[j][0]
+	})
+
+	switch {
+	case o.format == formatJSON && !o.quiet:
+		temp := make([]listEntryOutput, 0, len(entries))
+		for _, e := range entries {
+			temp = append(temp, listEntryOutput{Name: e[0], Password: e[1]})
+		}
+
+		return printJSON(temp)
+	case o.format == formatTable && !o.quiet:
+		rows := [][]string{{"NAME", "PASSWORD"}}
+		for _, e := range entries {
+			rows = append(rows, []string{e[0], e[1]})
+		}
+
+		return printTable(rows...)
+	case o.format == formatPlain || o.quiet:
+		for _, e := range entries {
+			//nolint
+			fmt.Printf("%s\t%s\n", e[0], e[1])
+		}
+
+		return nil
+	default:
+		//nolint
+		fmt.Println("-----------------")
+		//nolint
+		fmt.Println("List of passwords")
+		//nolint
+		fmt.Println("-----------------")
+
+		for i, e := range entries {
+			//nolint
+			fmt.Println(i, e[0], e[1])
+		}
+
+		return nil
+	}
+}
+
+// printResults prints the entries found by a search without their secrets unless "show" is set.
+func (o *output) printResults(results []passwdstore.Result, show bool) error {
+	// secret returns the secret of the entry to print, which is empty unless "show" is set.
+	secret := func(e passwdstore.Entry) string {
+		switch {
+		case !show:
+			return ""
+		case e.Data != nil:
+			return fmt.Sprintf("%d bytes", len(e.Data))
+		default:
+			return e.Password
+		}
+	}
+
+	if o.quiet {
+		for _, r := range results {
+			//nolint
+			fmt.Println(r.Name)
+		}
+
+		return nil
+	}
+
+	switch o.format {
+	case formatJSON:
+		temp := make([]searchOutput, 0, len(results))
+		for _, r := range results {
+			out := searchOutput{Name: r.Name, Username: r.Entry.Username, URL: r.Entry.URL, Tags: r.Entry.Tags, Matched: r.Fields}
+			if show {
+				out.Password, out.Data = r.Entry.Password, r.Entry.Data
+			}
+
+			temp = append(temp, out)
+		}
+
+		return printJSON(temp)
+	case formatTable, formatPlain:
+		var rows [][]string
+		if o.format == formatTable {
+			rows = append(rows, []string{"NAME", "USERNAME", "URL", "TAGS", "MATCHED"})
+			if show {
+				rows[0] = append(rows[0], "PASSWORD")
+			}
+		}
+
+		for _, r := range results {
+			row := []string{r.Name, r.Entry.Username, r.Entry.URL, strings.Join(r.Entry.Tags, ","), strings.Join(r.Fields, ",")}
+			if show {
+				row = append(row, secret(r.Entry))
+			}
+
+			rows = append(rows, row)
+		}
+
+		if o.format == formatTable {
+			return printTable(rows...)
+		}
+
+		for _, row := range rows {
+			//nolint
+			fmt.Println(strings.Join(row, "\t"))
+		}
+
+		return nil
+	default:
+		//nolint
+		fmt.Println("-----------------")
+		//nolint
+		fmt.Println("Search results")
+		//nolint
+		fmt.Println("-----------------")
+
+		for i, r := range results {
+			//nolint
+			fmt.Println(i, r.Name)
+
+			fields := [][2]string{
+				{"Username", r.Entry.Username},
+				{"URL", r.Entry.URL},
+				{"Tags", strings.Join(r.Entry.Tags, ",")},
+				{"Matched", strings.Join(r.Fields, ", ")},
+				{"Password", secret(r.Entry)},
+			}
+
+			for _, f := range fields {
+				if f[1] != "" {
+					//nolint
+					fmt.Println("  "+f[0]+":", f[1])
+				}
+			}
+		}
+
+		return nil
+	}
+}
+
+// printHistory prints the previous values of an entry numbered from 1, the most recent first.
+func (o *output) printHistory(history []passwdstore.Version) error {
+	// value returns the printable value of the version.
+	value := func(v passwdstore.Version) string {
+		if v.Data != nil {
+			return fmt.Sprintf("%d bytes", len(v.Data))
+		}
+
+		return v.Password
+	}
+
+	if o.quiet {
+		for _, v := range history {
+			//nolint
+			fmt.Println(value(v))
+		}
+
+		return nil
+	}
+
+	switch o.format {
+	case formatJSON:
+		temp := make([]versionOutput, 0, len(history))
+		for i, v := range history {
+			temp = append(temp, versionOutput{Version: i + 1, Password: v.Password, Data: v.Data, Replaced: v.Replaced})
+		}
+
+		return printJSON(temp)
+	case formatTable:
+		rows := [][]string{{"VERSION", "REPLACED", "PASSWORD"}}
+		for i, v := range history {
+			rows = append(rows, []string{strconv.Itoa(i + 1), v.Replaced.Local().Format(time.RFC3339), value(v)})
+		}
+
+		return printTable(rows...)
+	case formatPlain:
+		for i, v := range history {
+			//nolint
+			fmt.Printf("%d\t%s\t%s\n", i+1, v.Replaced.Local().Format(time.RFC3339), value(v))
+		}
+
+		return nil
+	default:
+		//nolint
+		fmt.Println("-----------------")
+		//nolint
+		fmt.Println("Previous passwords")
+		//nolint
+		fmt.Println("-----------------")
+
+		for i, v := range history {
+			//nolint
+			fmt.Println(i+1, v.Replaced.Local().Format(time.RFC1123), value(v))
+		}
+
+		return nil
+	}
+}
+
+// printOTP prints a one-time code along with the time it is valid for, which is 0 for counter based codes.
+func (o *output) printOTP(code string, remaining time.Duration) error {
+	seconds := int(remaining / time.Second)
+
+	switch {
+	case o.quiet || o.format == formatPlain:
+		//nolint
+		fmt.Println(code)
+
+		return nil
+	case o.format == formatJSON:
+		return printJSON(otpOutput{Code: code, Remaining: seconds})
+	case o.format == formatTable:
+		return printTable([]string{"CODE", "REMAINING"}, []string{code, strconv.Itoa(seconds)})
+	default:
+		//nolint
+		fmt.Println("Code:", code)
+
+		if remaining > 0 {
+			//nolint
+			fmt.Printf("Expires in: %ds\n", seconds)
+		}
+
+		return nil
+	}
+}
+
+// printGenerated prints a generated password along with its entropy in bits if it is known.
+func (o *output) printGenerated(pwd string, entropy float64) error {
+	switch {
+	case o.quiet || o.format == formatPlain:
+		//nolint
+		fmt.Println(pwd)
+
+		return nil
+	case o.format == formatJSON:
+		return printJSON(generatedOutput{Password: pwd, Entropy: entropy})
+	case o.format == formatTable && entropy > 0:
+		return printTable([]string{"PASSWORD", "ENTROPY"}, []string{pwd, fmt.Sprintf("%.1f", entropy)})
+	case o.format == formatTable:
+		return printTable([]string{"PASSWORD"}, []string{pwd})
+	default:
+		//nolint
+		fmt.Println("Generated password:", pwd)
+
+		if entropy > 0 {
+			//nolint
+			fmt.Printf("Entropy: %.1f bits\n", entropy)
+		}
+
+		return nil
+	}
+}
@@ -0,0 +1,131 @@
+package cli
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/231tr0n/vault/pkg/crypto"
+)
+
+// charClasses are the character classes which can be given to the -classes and -min flags.
+var charClasses = map[string]string{
+	"lower":   crypto.Lower,
+	"upper":   crypto.Upper,
+	"digits":  crypto.Digits,
+	"symbols": crypto.Symbols,
+}
+
+// minFlag is a flag which can be given multiple times to set the minimum count of a character class of the form class=count.
+type minFlag map[string]int
+
+func (f minFlag) String() string {
+	temp := make([]string, 0, len(f))
+	for k, v := range f {
+		temp = append(temp, k+"="+strconv.Itoa(v))
+	}
+
+	sort.Strings(temp)
+
+	return strings.Join(temp, ",")
+}
+
+func (f minFlag) Set(s string) error {
+	k, v, ok := strings.Cut(s, "=")
+	if _, found := charClasses[k]; !ok || !found {
+		return fmt.Errorf("%w: minimum count %q not of the form class=count", ErrUsage, s)
+	}
+
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 {
+		return fmt.Errorf("%w: invalid minimum count %q", ErrUsage, v)
+	}
+
+	f[k] = n
+
+	return nil
+}
+
+// policyFlags holds the flags of a command which generates passwords.
+type policyFlags struct {
+	classes     string
+	min         minFlag
+	alphabet    string
+	exclude     string
+	noAmbiguous bool
+}
+
+// addPolicyFlags adds the flags describing the generated passwords to the command.
+func addPolicyFlags(c *command) *policyFlags {
+	p := &policyFlags{min: minFlag{}}
+	c.fs.StringVar(&p.classes, "classes", "lower,upper,digits,symbols", "Comma separated character classes of the generated password, "+
+		"any of lower, upper, digits and symbols. The password has at least one character of each class.")
+	c.fs.Var(p.min, "min", "Sets the minimum count of a character class of the form class=count, like digits=3. Can be given multiple times.")
+	c.fs.StringVar(&p.alphabet, "alphabet", "", "Generates the password from the characters given instead of the character classes.")
+	c.fs.StringVar(&p.exclude, "exclude", "", "Characters which are never used in the generated password.")
+	c.fs.BoolVar(&p.noAmbiguous, "no-ambiguous", false, "Excludes the characters which are easily confused like 0, O, 1, l and I.")
+
+	return p
+}
+
+// policy returns the password policy of the flags for passwords of length "l".
+func (p *policyFlags) policy(l int) (crypto.Policy, error) {
+	policy := crypto.Policy{Length: l, Exclude: p.exclude}
+
+	if p.noAmbiguous {
+		policy.Exclude += crypto.Ambiguous
+	}
+
+	if p.alphabet != "" {
+		if len(p.min) > 0 {
+			return crypto.Policy{}, fmt.Errorf("%w: -min can't be given along with -alphabet", ErrUsage)
+		}
+
+		policy.Classes = []crypto.CharClass{{Chars: p.alphabet}}
+
+		return policy, nil
+	}
+
+	given := make(map[string]bool)
+
+	for _, name := range strings.Split(p.classes, ",") {
+		chars, ok := charClasses[name]
+		if !ok {
+			return crypto.Policy{}, fmt.Errorf("%w: unknown character class %q", ErrUsage, name)
+		}
+
+		if given[name] {
+			continue
+		}
+
+		given[name] = true
+		minCount := 1
+
+		if n, ok := p.min[name]; ok {
+			minCount = n
+		}
+
+		policy.Classes = append(policy.Classes, crypto.CharClass{Chars: chars, Min: minCount})
+	}
+
+	for name := range p.min {
+		if !given[name] {
+			return crypto.Policy{}, fmt.Errorf("%w: -min given for the character class %q which is not in -classes", ErrUsage, name)
+		}
+	}
+
+	return policy, nil
+}
+
+// generate generates a password of length "l" with the policy of the flags.
+func (p *policyFlags) generate(l int) ([]byte, error) {
+	policy, err := p.policy(l)
+	if err != nil {
+		return nil, err
+	}
+
+	passwd, err := policy.Generate()
+
+	return passwd, wrap(err)
+}
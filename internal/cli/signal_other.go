@@ -0,0 +1,10 @@
+//go:build js || wasip1 || plan9
+
+package cli
+
+import (
+	"os"
+)
+
+// clearSignals are the signals which clear the clipboard right away.
+var clearSignals = []os.Signal{os.Interrupt}
@@ -0,0 +1,11 @@
+//go:build !(js || wasip1 || plan9)
+
+package cli
+
+import (
+	"os"
+	"syscall"
+)
+
+// clearSignals are the signals which clear the clipboard right away.
+var clearSignals = []os.Signal{os.Interrupt, syscall.SIGTERM, syscall.SIGHUP}
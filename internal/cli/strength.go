@@ -0,0 +1,50 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/231tr0n/vault/pkg/strength"
+)
+
+// defaultMinScore is the default minimum strength score of passwords, from 0 to strength.MaxScore.
+const defaultMinScore = 3
+
+// errWeakPasswd is the error thrown when the new vault password is weaker than the minimum score.
+var errWeakPasswd = errors.New("cli: vault password is too weak, give -force to set it anyway")
+
+// addMinScoreFlag adds the -min-score flag to the command.
+func addMinScoreFlag(c *command, desc string) *int {
+	return c.fs.Int("min-score", defaultMinScore, desc+fmt.Sprintf(" From 0, too guessable, to %d, very unguessable.", strength.MaxScore))
+}
+
+// checkMinScore checks that the minimum strength score given with -min-score is in range.
+func checkMinScore(minScore int) error {
+	if minScore < 0 || minScore > strength.MaxScore {
+		return fmt.Errorf("%w: -min-score must be from 0 to %d", ErrUsage, strength.MaxScore)
+	}
+
+	return nil
+}
+
+// checkStrength estimates the strength of the password "p" and returns the weaknesses found if its score is below "minScore".
+// The inputs are words the password should not be based on, like the name of the entry.
+func checkStrength(p []byte, minScore int, inputs ...string) (string, error) {
+	err := checkMinScore(minScore)
+	if err != nil {
+		return "", err
+	}
+
+	r := strength.Estimate(string(p), inputs...)
+	if r.Score >= minScore {
+		return "", nil
+	}
+
+	temp := []string{fmt.Sprintf("score %d of %d.", r.Score, strength.MaxScore)}
+	if r.Warning != "" {
+		temp = append(temp, r.Warning)
+	}
+
+	return strings.Join(append(temp, r.Suggestions...), " "), nil
+}
@@ -0,0 +1,208 @@
+package clipboard
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"time"
+)
+
+func wrap(err error) error {
+	if err != nil {
+		return fmt.Errorf("clipboard: %w", err)
+	}
+
+	return nil
+}
+
+// BackendEnv is the environment variable which selects the backend by name instead of detecting it.
+const BackendEnv = "VAULT_CLIPBOARD"
+
+var (
+	// ErrNoBackend is the error thrown when no clipboard backend is found.
+	ErrNoBackend = errors.New("clipboard: no clipboard backend found, install wl-clipboard, xclip or xsel")
+	// ErrUnknownBackend is the error thrown when the backend selected by name does not exist.
+	ErrUnknownBackend = errors.New("clipboard: unknown clipboard backend")
+	// ErrPasteUnsupported is the error thrown when the backend can't read the clipboard.
+	ErrPasteUnsupported = errors.New("clipboard: backend can't read the clipboard")
+)
+
+// Backend reads and writes the clipboard.
+type Backend interface {
+	// Name returns the name of the backend, which is used to select it with BackendEnv.
+	Name() string
+	// Copy writes "b" to the clipboard.
+	Copy(b []byte) error
+	// Paste reads the clipboard. It fails with ErrPasteUnsupported if the backend can't read it.
+	Paste() ([]byte, error)
+	// Clear empties the clipboard.
+	Clear() error
+}
+
+// commandBackend is a backend which runs commands like xclip to read and write the clipboard.
+type commandBackend struct {
+	name  string
+	copy  []string
+	paste []string
+	clear []string
+	// env is the environment variable which has to be set for the backend to be detected, like DISPLAY.
+	env string
+}
+
+func (c *commandBackend) Name() string {
+	return c.name
+}
+
+// run runs the command with "in" as stdin.
+// Its output is discarded as commands like xclip fork to serve the clipboard and would keep a pipe open.
+func run(args []string, in []byte) error {
+	//nolint
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdin = bytes.NewReader(in)
+
+	return wrap(cmd.Run())
+}
+
+func (c *commandBackend) Copy(b []byte) error {
+	return run(c.copy, b)
+}
+
+func (c *commandBackend) Paste() ([]byte, error) {
+	//nolint
+	out, err := exec.Command(c.paste[0], c.paste[1:]...).Output()
+
+	return out, wrap(err)
+}
+
+func (c *commandBackend) Clear() error {
+	if c.clear == nil {
+		return c.Copy(nil)
+	}
+
+	return run(c.clear, nil)
+}
+
+// available reports if the command of the backend is installed and its display is set.
+func (c *commandBackend) available() bool {
+	if c.env != "" && os.Getenv(c.env) == "" {
+		return false
+	}
+
+	_, err := exec.LookPath(c.copy[0])
+
+	return err == nil
+}
+
+// osc52 is a backend which writes the clipboard with the OSC 52 escape sequence of the terminal.
+// It works over ssh but can't read the clipboard.
+type osc52 struct {
+	w io.Writer
+}
+
+func (o *osc52) Name() string {
+	return "osc52"
+}
+
+func (o *osc52) Copy(b []byte) error {
+	_, err := fmt.Fprintf(o.w, "\x1b]52;c;%s\a", base64.StdEncoding.EncodeToString(b))
+
+	return wrap(err)
+}
+
+func (o *osc52) Paste() ([]byte, error) {
+	return nil, ErrPasteUnsupported
+}
+
+func (o *osc52) Clear() error {
+	return o.Copy(nil)
+}
+
+// commandBackends are the backends which run commands in the order they are detected.
+var commandBackends = []*commandBackend{
+	{
+		name:  "pbcopy",
+		copy:  []string{"pbcopy"},
+		paste: []string{"pbpaste"},
+	},
+	{
+		name:  "wl-copy",
+		copy:  []string{"wl-copy"},
+		paste: []string{"wl-paste", "-n"},
+		clear: []string{"wl-copy", "--clear"},
+		env:   "WAYLAND_DISPLAY",
+	},
+	{
+		name:  "xclip",
+		copy:  []string{"xclip", "-selection", "clipboard"},
+		paste: []string{"xclip", "-selection", "clipboard", "-o"},
+		env:   "DISPLAY",
+	},
+	{
+		name:  "xsel",
+		copy:  []string{"xsel", "--clipboard", "--input"},
+		paste: []string{"xsel", "--clipboard", "--output"},
+		clear: []string{"xsel", "--clipboard", "--clear"},
+		env:   "DISPLAY",
+	},
+}
+
+// Detect returns the backend selected with BackendEnv, or else the first available one of
+// pbcopy on macOS, wl-copy on wayland, xclip and xsel on X11 and finally OSC 52 if there is a terminal.
+func Detect() (Backend, error) {
+	name := os.Getenv(BackendEnv)
+
+	for _, c := range commandBackends {
+		if name == c.name {
+			return c, nil
+		}
+
+		if name == "" && c.available() && (c.name != "pbcopy" || runtime.GOOS == "darwin") {
+			return c, nil
+		}
+	}
+
+	if name != "" && name != "osc52" {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownBackend, name)
+	}
+
+	tty, err := os.OpenFile("/dev/tty", os.O_WRONLY, 0)
+	if err != nil {
+		if name == "" {
+			return nil, ErrNoBackend
+		}
+
+		return &osc52{w: os.Stderr}, nil
+	}
+
+	return &osc52{w: tty}, nil
+}
+
+// ClearAfter waits till the timeout is over or the context is done and then clears the clipboard,
+// but only if it still holds "b" so that anything copied meanwhile is kept.
+// Backends which can't read the clipboard, like OSC 52, always clear it.
+func ClearAfter(ctx context.Context, backend Backend, b []byte, timeout time.Duration) error {
+	t := time.NewTimer(timeout)
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+	case <-ctx.Done():
+	}
+
+	current, err := backend.Paste()
+	if err != nil && !errors.Is(err, ErrPasteUnsupported) {
+		return err
+	}
+
+	if err == nil && !bytes.Equal(current, b) {
+		return nil
+	}
+
+	return backend.Clear()
+}
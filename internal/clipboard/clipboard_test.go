@@ -0,0 +1,116 @@
+package clipboard_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/231tr0n/vault/internal/clipboard"
+	"github.com/231tr0n/vault/internal/clipboard/clipboardtest"
+)
+
+func failTestCase(t *testing.T, i, o, w any) {
+	t.Helper()
+	t.Error("Input:", i, "|", "Output:", o, "|", "Want:", w)
+}
+
+func TestClearAfter(t *testing.T) {
+	t.Parallel()
+
+	type test struct {
+		// copied is copied to the clipboard before it is cleared, if not nil.
+		copied []byte
+		want   []byte
+	}
+
+	secret := []byte("secret")
+
+	tests := []test{
+		{nil, nil},
+		{[]byte("copied meanwhile"), []byte("copied meanwhile")},
+	}
+
+	for _, test := range tests {
+		t.Log(test)
+
+		fake := &clipboardtest.Fake{}
+
+		err := fake.Copy(secret)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if test.copied != nil {
+			err = fake.Copy(test.copied)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		err = clipboard.ClearAfter(context.Background(), fake, secret, time.Millisecond)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		out, err := fake.Paste()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !bytes.Equal(out, test.want) {
+			failTestCase(t, test, out, test.want)
+		}
+	}
+}
+
+func TestClearAfterCancel(t *testing.T) {
+	t.Parallel()
+
+	fake := &clipboardtest.Fake{}
+
+	err := fake.Copy([]byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	start := time.Now()
+
+	err = clipboard.ClearAfter(ctx, fake, []byte("secret"), time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := fake.Paste()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(out) != 0 || time.Since(start) > time.Minute {
+		failTestCase(t, "cancelled", out, "cleared without waiting")
+	}
+}
+
+func TestDetect(t *testing.T) {
+	t.Setenv(clipboard.BackendEnv, "xsel")
+
+	b, err := clipboard.Detect()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if b.Name() != "xsel" {
+		failTestCase(t, "xsel", b.Name(), "xsel")
+	}
+
+	t.Setenv(clipboard.BackendEnv, "nothing")
+
+	_, err = clipboard.Detect()
+	if !errors.Is(err, clipboard.ErrUnknownBackend) {
+		failTestCase(t, "nothing", err, clipboard.ErrUnknownBackend)
+	}
+}
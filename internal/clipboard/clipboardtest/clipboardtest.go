@@ -0,0 +1,38 @@
+/*
+Package clipboardtest implements an in memory clipboard backend for tests.
+*/
+package clipboardtest
+
+import (
+	"sync"
+)
+
+// Fake is an in memory clipboard.Backend for tests.
+type Fake struct {
+	mu   sync.Mutex
+	data []byte
+}
+
+func (f *Fake) Name() string {
+	return "fake"
+}
+
+func (f *Fake) Copy(b []byte) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.data = append([]byte{}, b...)
+
+	return nil
+}
+
+func (f *Fake) Paste() ([]byte, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return append([]byte{}, f.data...), nil
+}
+
+func (f *Fake) Clear() error {
+	return f.Copy(nil)
+}
@@ -0,0 +1,6 @@
+/*
+Package clipboard copies secrets to the clipboard and clears them after a timeout.
+The clipboard is written with wl-copy, xclip, xsel, pbcopy or the OSC 52 escape sequence of the terminal
+through the Backend interface, which tests can fake with clipboardtest.Fake.
+*/
+package clipboard
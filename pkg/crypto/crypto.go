@@ -28,16 +28,15 @@ func wrap(err error) error {
 	return nil
 }
 
-// Encrypt encrypts "s" with password "p" using aes and gcm.
-func Encrypt(s, p []byte) ([]byte, error) {
-	if len(p)%aes256KeySize != 0 {
-		temp := aes256KeySize - (len(p) % aes256KeySize)
-		for i := 0; i < temp; i++ {
-			p = append(p, '0')
-		}
+// Encrypt encrypts "s" with the aes256 key "k" using aes and gcm.
+// The additional data "a" is not encrypted but is authenticated along with "s", it can be nil.
+// Use DeriveKey to turn a password into a key.
+func Encrypt(s, k, a []byte) ([]byte, error) {
+	if len(k) != aes256KeySize {
+		return nil, ErrInvalidKeySize
 	}
 
-	cr, err := aes.NewCipher(p)
+	cr, err := aes.NewCipher(k)
 	if err != nil {
 		return nil, wrap(err)
 	}
@@ -53,24 +52,23 @@ func Encrypt(s, p []byte) ([]byte, error) {
 		return nil, wrap(err)
 	}
 
-	return []byte(hex.EncodeToString(gcm.Seal(nonce, nonce, s, nil))), nil
+	return []byte(hex.EncodeToString(gcm.Seal(nonce, nonce, s, a))), nil
 }
 
-// Decrypt decrypts "s" with password "p" using aes and gcm.
-func Decrypt(s, p []byte) ([]byte, error) {
+// Decrypt decrypts "s" with the aes256 key "k" using aes and gcm.
+// The additional data "a" must be the same as the one given to Encrypt.
+// Use DeriveKey to turn a password into a key.
+func Decrypt(s, k, a []byte) ([]byte, error) {
 	s, err := hex.DecodeString(string(s))
 	if err != nil {
 		return nil, wrap(err)
 	}
 
-	if len(p)%aes256KeySize != 0 {
-		temp := aes256KeySize - (len(p) % aes256KeySize)
-		for i := 0; i < temp; i++ {
-			p = append(p, '0')
-		}
+	if len(k) != aes256KeySize {
+		return nil, ErrInvalidKeySize
 	}
 
-	cr, err := aes.NewCipher(p)
+	cr, err := aes.NewCipher(k)
 	if err != nil {
 		return nil, wrap(err)
 	}
@@ -81,9 +79,13 @@ func Decrypt(s, p []byte) ([]byte, error) {
 	}
 
 	nonceSize := gcm.NonceSize()
+	if len(s) < nonceSize {
+		return nil, ErrWrongPasswd
+	}
+
 	nonce, ct := s[:nonceSize], s[nonceSize:]
 
-	out, err := gcm.Open(nil, nonce, ct, nil)
+	out, err := gcm.Open(nil, nonce, ct, a)
 	if err != nil {
 		return nil, ErrWrongPasswd
 	}
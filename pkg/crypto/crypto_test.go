@@ -1,7 +1,10 @@
 package crypto_test
 
 import (
+	"errors"
+	"strings"
 	"testing"
+	"unicode/utf8"
 
 	"github.com/231tr0n/vault/pkg/crypto"
 )
@@ -191,16 +194,26 @@ func TestEncryptAndDecrypt(t *testing.T) {
 		},
 	}
 
+	params, err := crypto.NewKDFParams()
+	if err != nil {
+		t.Fatal(err)
+	}
+
 	for _, test := range tests {
 		t.Log(test)
-		eout, err := crypto.Encrypt(test[0], test[1])
+		key, err := crypto.DeriveKey(test[1], params)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		eout, err := crypto.Encrypt(test[0], key, test[1])
 		if err != nil {
 			t.Fatal(err)
 		}
 
 		var dout []byte
 
-		dout, err = crypto.Decrypt(eout, test[1])
+		dout, err = crypto.Decrypt(eout, key, test[1])
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -218,3 +231,413 @@ func TestEncryptAndDecrypt(t *testing.T) {
 		}
 	}
 }
+
+func TestDecryptWrongKeyOrData(t *testing.T) {
+	t.Parallel()
+
+	params, err := crypto.NewKDFParams()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key, err := crypto.DeriveKey([]byte("key"), params)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wrongKey, err := crypto.DeriveKey([]byte("wrongkey"), params)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	eout, err := crypto.Encrypt([]byte("hi"), key, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = crypto.Decrypt(eout, wrongKey, nil)
+	if !errors.Is(err, crypto.ErrWrongPasswd) {
+		failTestCase(t, "wrongkey", err, crypto.ErrWrongPasswd)
+	}
+
+	_, err = crypto.Decrypt(eout, key, []byte("ad"))
+	if !errors.Is(err, crypto.ErrWrongPasswd) {
+		failTestCase(t, "different additional data", err, crypto.ErrWrongPasswd)
+	}
+
+	_, err = crypto.Encrypt([]byte("hi"), []byte("shortkey"), nil)
+	if !errors.Is(err, crypto.ErrInvalidKeySize) {
+		failTestCase(t, "shortkey", err, crypto.ErrInvalidKeySize)
+	}
+}
+
+func TestDeriveKey(t *testing.T) {
+	t.Parallel()
+
+	params, err := crypto.NewKDFParams()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	otherParams, err := crypto.NewKDFParams()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := [][]byte{
+		[]byte("k"),
+		[]byte("keykeykekeykeykekeykeykekeykeyke"),
+		[]byte("keykeykekeykeykekeykeykekeykeykek"),
+	}
+
+	for _, test := range tests {
+		t.Log(test)
+		key, err := crypto.DeriveKey(test, params)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(key) != 32 {
+			failTestCase(t, string(test), len(key), 32)
+		}
+
+		again, err := crypto.DeriveKey(test, params)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !crypto.Verify(key, again) {
+			failTestCase(t, string(test), "different keys for same salt", "same key")
+		}
+
+		other, err := crypto.DeriveKey(test, otherParams)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if crypto.Verify(key, other) {
+			failTestCase(t, string(test), "same key for different salts", "different keys")
+		}
+	}
+}
+
+func TestDeriveKeyLegacy(t *testing.T) {
+	t.Parallel()
+
+	params := crypto.KDFParams{KDF: crypto.KDFLegacy}
+
+	key, err := crypto.DeriveKey([]byte("key"), params)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "key00000000000000000000000000000"
+	if string(key) != want {
+		failTestCase(t, "key", string(key), want)
+	}
+
+	_, err = crypto.DeriveKey([]byte("keykeykekeykeykekeykeykekeykeykek"), params)
+	if !errors.Is(err, crypto.ErrInvalidKeySize) {
+		failTestCase(t, "33 byte password", err, crypto.ErrInvalidKeySize)
+	}
+}
+
+func TestKDFParamsMarshalBinary(t *testing.T) {
+	t.Parallel()
+
+	params, err := crypto.NewKDFParams()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := params.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out crypto.KDFParams
+
+	err = out.UnmarshalBinary(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if out.KDF != params.KDF || out.Time != params.Time || out.Memory != params.Memory ||
+		out.Threads != params.Threads || !crypto.Verify(out.Salt, params.Salt) {
+		failTestCase(t, params, out, params)
+	}
+
+	err = out.UnmarshalBinary(b[:len(b)-1])
+	if !errors.Is(err, crypto.ErrInvalidKDFParams) {
+		failTestCase(t, "truncated params", err, crypto.ErrInvalidKDFParams)
+	}
+}
+
+func TestKDFParamsOutOfRange(t *testing.T) {
+	t.Parallel()
+
+	params, err := crypto.NewKDFParams()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []crypto.KDFParams{
+		{KDF: crypto.KDFArgon2id, Salt: params.Salt, Time: params.Time, Memory: 0xffffffff, Threads: params.Threads},
+		{KDF: crypto.KDFArgon2id, Salt: params.Salt, Time: crypto.MaxArgon2idTime + 1, Memory: params.Memory, Threads: params.Threads},
+		{KDF: crypto.KDFArgon2id, Salt: params.Salt, Time: 0xffffffff, Memory: params.Memory, Threads: params.Threads},
+		{KDF: crypto.KDFArgon2id, Salt: params.Salt, Time: params.Time, Memory: params.Memory, Threads: 0},
+	}
+
+	for _, test := range tests {
+		t.Log(test)
+
+		b, err := test.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var out crypto.KDFParams
+
+		err = out.UnmarshalBinary(b)
+		if !errors.Is(err, crypto.ErrInvalidKDFParams) {
+			failTestCase(t, test, err, crypto.ErrInvalidKDFParams)
+		}
+
+		_, err = crypto.DeriveKey([]byte("secret"), test)
+		if !errors.Is(err, crypto.ErrInvalidKDFParams) {
+			failTestCase(t, test, err, crypto.ErrInvalidKDFParams)
+		}
+	}
+}
+
+func TestKeyCheck(t *testing.T) {
+	t.Parallel()
+
+	params, err := crypto.NewKDFParams()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key, err := crypto.DeriveKey([]byte("key"), params)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wrongKey, err := crypto.DeriveKey([]byte("wrongkey"), params)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	kc, err := crypto.KeyCheck(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	again, err := crypto.KeyCheck(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !crypto.HmacVerify(kc, again) {
+		failTestCase(t, "key", string(again), string(kc))
+	}
+
+	wrong, err := crypto.KeyCheck(wrongKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if crypto.HmacVerify(kc, wrong) {
+		failTestCase(t, "wrongkey", string(wrong), "different key check value")
+	}
+}
+
+func TestPolicyGenerate(t *testing.T) {
+	t.Parallel()
+
+	count := func(s, chars string) int {
+		n := 0
+
+		for _, r := range s {
+			if strings.ContainsRune(chars, r) {
+				n++
+			}
+		}
+
+		return n
+	}
+
+	tests := []crypto.Policy{
+		crypto.DefaultPolicy(4),
+		crypto.DefaultPolicy(64),
+		{
+			Length: 30,
+			Classes: []crypto.CharClass{
+				{Chars: crypto.Lower, Min: 10},
+				{Chars: crypto.Digits, Min: 10},
+				{Chars: crypto.Symbols, Min: 10},
+			},
+		},
+		{
+			Length:  50,
+			Classes: []crypto.CharClass{{Chars: crypto.Lower + crypto.Upper + crypto.Digits}},
+			Exclude: crypto.Ambiguous,
+		},
+		{
+			Length:  10,
+			Classes: []crypto.CharClass{{Chars: "αβγ"}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Log(test)
+		out, err := test.Generate()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if utf8.RuneCount(out) != test.Length {
+			failTestCase(t, test, string(out), test.Length)
+		}
+
+		all := ""
+		for _, c := range test.Classes {
+			all += c.Chars
+
+			if count(string(out), c.Chars) < c.Min {
+				failTestCase(t, test, string(out), c)
+			}
+		}
+
+		if count(string(out), all) != test.Length || count(string(out), test.Exclude) != 0 {
+			failTestCase(t, test, string(out), "only characters of the classes and none excluded")
+		}
+	}
+
+	out, err := crypto.Policy{Length: 10000, Classes: []crypto.CharClass{{Chars: "ab"}}}.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n := strings.Count(string(out), "a"); n < 4500 || n > 5500 {
+		failTestCase(t, "ab", n, "about 5000")
+	}
+}
+
+func TestInvalidPolicy(t *testing.T) {
+	t.Parallel()
+
+	tests := []crypto.Policy{
+		crypto.DefaultPolicy(0),
+		crypto.DefaultPolicy(3),
+		{Length: 10},
+		{Length: 10, Classes: []crypto.CharClass{{Chars: "01"}}, Exclude: crypto.Ambiguous},
+		{Length: 10, Classes: []crypto.CharClass{{Chars: "ab", Min: -1}}},
+	}
+
+	for _, test := range tests {
+		t.Log(test)
+		_, err := test.Generate()
+
+		if !errors.Is(err, crypto.ErrInvalidPolicy) {
+			failTestCase(t, test, err, crypto.ErrInvalidPolicy)
+		}
+	}
+}
+
+func TestWordlist(t *testing.T) {
+	t.Parallel()
+
+	words := crypto.Wordlist()
+
+	if len(words) != 7776 || words[0] != "abacus" || words[len(words)-1] != "zoom" {
+		failTestCase(t, "eff large wordlist", len(words), 7776)
+	}
+}
+
+func TestPassphrasePolicyGenerate(t *testing.T) {
+	t.Parallel()
+
+	words := make(map[string]bool)
+	for _, w := range crypto.Wordlist() {
+		words[w] = true
+	}
+
+	// inserted returns the number of characters of "chars" which have to be removed from "w" to get a word of the wordlist.
+	inserted := func(w, chars string) int {
+		w = strings.ToLower(w)
+		if words[w] {
+			return 0
+		}
+
+		for i, r := range w {
+			if strings.ContainsRune(chars, r) && words[w[:i]+w[i+1:]] {
+				return 1
+			}
+		}
+
+		return -1
+	}
+
+	type test struct {
+		policy  crypto.PassphrasePolicy
+		entropy float64
+	}
+
+	tests := []test{
+		{crypto.PassphrasePolicy{Words: 6, Separator: " "}, 77.549},
+		{crypto.PassphrasePolicy{Words: 1, Separator: " ", Capitalize: true}, 12.925},
+		{crypto.PassphrasePolicy{Words: 4, Separator: " ", Digit: true}, 51.699 + 5.322},
+		{crypto.PassphrasePolicy{Words: 4, Separator: " ", Symbol: true}, 51.699 + 7},
+	}
+
+	for _, test := range tests {
+		t.Log(test)
+		out, err := test.policy.Generate()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if e := test.policy.Entropy(); e < test.entropy-0.01 || e > test.entropy+0.01 {
+			failTestCase(t, test, e, test.entropy)
+		}
+
+		parts := strings.Split(string(out), test.policy.Separator)
+		if len(parts) != test.policy.Words {
+			failTestCase(t, test, string(out), test.policy.Words)
+		}
+
+		chars := ""
+		if test.policy.Digit {
+			chars = crypto.Digits
+		}
+
+		if test.policy.Symbol {
+			chars = crypto.Symbols
+		}
+
+		count := 0
+
+		for _, part := range parts {
+			if test.policy.Capitalize && part[:1] != strings.ToUpper(part[:1]) {
+				failTestCase(t, test, string(out), "capitalized words")
+			}
+
+			n := inserted(part, chars)
+			if n < 0 {
+				failTestCase(t, test, string(out), "words of the wordlist")
+			}
+
+			count += n
+		}
+
+		if chars != "" && count != 1 {
+			failTestCase(t, test, string(out), "one inserted character")
+		}
+	}
+
+	_, err := crypto.PassphrasePolicy{}.Generate()
+	if !errors.Is(err, crypto.ErrInvalidPolicy) {
+		failTestCase(t, crypto.PassphrasePolicy{}, err, crypto.ErrInvalidPolicy)
+	}
+}
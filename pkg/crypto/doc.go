@@ -1,7 +1,9 @@
 /*
 Package crypto implements the following:-
 Basic encryption using aes and gcm
+Key derivation from passwords using argon2id
 Hmac with sha256 based hashing
-Random string generation.
+Random string generation
+Password generation with policies of character classes.
 */
 package crypto
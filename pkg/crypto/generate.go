@@ -0,0 +1,165 @@
+package crypto
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// Character classes of generated passwords.
+const (
+	Lower   = "abcdefghijklmnopqrstuvwxyz"
+	Upper   = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	Digits  = "0123456789"
+	Symbols = "!\"#$%&'()*+,-./:;<=>?@[\\]^_`{|}~"
+	// Ambiguous are the characters which are easily confused with each other when read.
+	Ambiguous = "0Oo1Il|'`\""
+)
+
+// ErrInvalidPolicy is the error thrown when no password can be generated with the policy.
+var ErrInvalidPolicy = errors.New("crypto: invalid password policy")
+
+// CharClass is a set of characters along with the minimum number of them in a generated password.
+type CharClass struct {
+	Chars string
+	Min   int
+}
+
+// Policy describes the passwords generated by Generate.
+// A password is made of the characters of all the classes, with at least Min characters of each class.
+// A custom alphabet is a single class with the characters of the alphabet.
+type Policy struct {
+	Length  int
+	Classes []CharClass
+	// Exclude are the characters which are never used, like Ambiguous.
+	Exclude string
+}
+
+// DefaultPolicy returns the policy of passwords of length "l" with at least one lower case letter,
+// upper case letter, digit and symbol.
+func DefaultPolicy(l int) Policy {
+	return Policy{
+		Length: l,
+		Classes: []CharClass{
+			{Chars: Lower, Min: 1},
+			{Chars: Upper, Min: 1},
+			{Chars: Digits, Min: 1},
+			{Chars: Symbols, Min: 1},
+		},
+	}
+}
+
+// classes returns the characters of each class without the excluded characters and the union of them all without duplicates.
+func (p Policy) classes() ([][]rune, []rune, error) {
+	if p.Length <= 0 {
+		return nil, nil, fmt.Errorf("%w: length must be positive", ErrInvalidPolicy)
+	}
+
+	if len(p.Classes) == 0 {
+		return nil, nil, fmt.Errorf("%w: no character classes", ErrInvalidPolicy)
+	}
+
+	classes := make([][]rune, 0, len(p.Classes))
+	alphabet := make([]rune, 0)
+	seen := make(map[rune]bool)
+	total := 0
+
+	for _, c := range p.Classes {
+		if c.Min < 0 {
+			return nil, nil, fmt.Errorf("%w: negative minimum count", ErrInvalidPolicy)
+		}
+
+		chars := make([]rune, 0, len(c.Chars))
+
+		for _, r := range c.Chars {
+			if strings.ContainsRune(p.Exclude, r) {
+				continue
+			}
+
+			chars = append(chars, r)
+
+			if !seen[r] {
+				seen[r] = true
+				alphabet = append(alphabet, r)
+			}
+		}
+
+		if len(chars) == 0 {
+			return nil, nil, fmt.Errorf("%w: character class %q is empty", ErrInvalidPolicy, c.Chars)
+		}
+
+		classes = append(classes, chars)
+		total += c.Min
+	}
+
+	if total > p.Length {
+		return nil, nil, fmt.Errorf("%w: minimum counts add up to more than the length %d", ErrInvalidPolicy, p.Length)
+	}
+
+	return classes, alphabet, nil
+}
+
+// randInt returns a uniform random number in [0, n).
+func randInt(n int) (int, error) {
+	i, err := rand.Int(rand.Reader, big.NewInt(int64(n)))
+	if err != nil {
+		return 0, wrap(err)
+	}
+
+	return int(i.Int64()), nil
+}
+
+// pick returns a uniform random character of "chars".
+func pick(chars []rune) (rune, error) {
+	i, err := randInt(len(chars))
+	if err != nil {
+		return 0, err
+	}
+
+	return chars[i], nil
+}
+
+// Generate generates a random password with the policy.
+// The minimum counts of each class are picked first and the rest of the password is picked from all the characters,
+// each character uniformly at random, after which the password is shuffled so that the positions of the classes are random too.
+func (p Policy) Generate() ([]byte, error) {
+	classes, alphabet, err := p.classes()
+	if err != nil {
+		return nil, err
+	}
+
+	passwd := make([]rune, 0, p.Length)
+
+	for i, c := range p.Classes {
+		for j := 0; j < c.Min; j++ {
+			r, err := pick(classes[i])
+			if err != nil {
+				return nil, err
+			}
+
+			passwd = append(passwd, r)
+		}
+	}
+
+	for len(passwd) < p.Length {
+		r, err := pick(alphabet)
+		if err != nil {
+			return nil, err
+		}
+
+		passwd = append(passwd, r)
+	}
+
+	for i := len(passwd) - 1; i > 0; i-- {
+		j, err := randInt(i + 1)
+		if err != nil {
+			return nil, err
+		}
+
+		passwd[i], passwd[j] = passwd[j], passwd[i]
+	}
+
+	return []byte(string(passwd)), nil
+}
@@ -0,0 +1,158 @@
+package crypto
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"io"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// KDF identifies the key derivation function used to turn a password into an aes256 key.
+type KDF uint8
+
+const (
+	// KDFLegacy pads the password with '0' bytes till it is 32 bytes long.
+	// It is insecure and is only kept for reading files written by older versions.
+	KDFLegacy KDF = iota
+	// KDFArgon2id derives the key using argon2id with a random salt.
+	KDFArgon2id
+)
+
+const (
+	// DefaultArgon2idTime is the default number of passes over the memory.
+	DefaultArgon2idTime = 3
+	// DefaultArgon2idMemory is the default memory used in KiB.
+	DefaultArgon2idMemory = 64 * 1024
+	// DefaultArgon2idThreads is the default degree of parallelism.
+	DefaultArgon2idThreads = 4
+	// MaxArgon2idTime is the largest number of passes over the memory which is accepted.
+	MaxArgon2idTime = 64
+	// MaxArgon2idMemory is the largest memory in KiB which is accepted, 4 GiB.
+	MaxArgon2idMemory = 4 * 1024 * 1024
+
+	saltSize = 16
+	// keyCheckLabel is the message hashed with the key to get the key check value.
+	keyCheckLabel = "vault key check"
+	// kdfParamsHeaderSize is the size of the encoded params without the salt.
+	kdfParamsHeaderSize = 11
+)
+
+var (
+	// ErrInvalidKeySize is the error thrown when the key is not an aes256 key.
+	ErrInvalidKeySize = errors.New("crypto: invalid key size")
+	// ErrUnknownKDF is the error thrown when the key derivation function is not supported.
+	ErrUnknownKDF = errors.New("crypto: unknown key derivation function")
+	// ErrInvalidKDFParams is the error thrown when the encoded key derivation params are not parsable
+	// or their cost parameters are out of range.
+	ErrInvalidKDFParams = errors.New("crypto: invalid key derivation params")
+)
+
+// KDFParams holds the key derivation function along with its salt and tunable cost parameters.
+// It is stored in plain text along with the encrypted data so that the key can be derived again.
+type KDFParams struct {
+	KDF     KDF
+	Salt    []byte
+	Time    uint32
+	Memory  uint32
+	Threads uint8
+}
+
+// NewKDFParams returns argon2id params with the default cost parameters and a new random salt.
+func NewKDFParams() (KDFParams, error) {
+	salt := make([]byte, saltSize)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return KDFParams{}, wrap(err)
+	}
+
+	return KDFParams{
+		KDF:     KDFArgon2id,
+		Salt:    salt,
+		Time:    DefaultArgon2idTime,
+		Memory:  DefaultArgon2idMemory,
+		Threads: DefaultArgon2idThreads,
+	}, nil
+}
+
+// DeriveKey derives an aes256 key from password "p" using the key derivation function in "params".
+func DeriveKey(p []byte, params KDFParams) ([]byte, error) {
+	switch params.KDF {
+	case KDFLegacy:
+		key := append([]byte{}, p...)
+		if len(key)%aes256KeySize != 0 {
+			temp := aes256KeySize - (len(key) % aes256KeySize)
+			for i := 0; i < temp; i++ {
+				key = append(key, '0')
+			}
+		}
+
+		if len(key) != aes256KeySize {
+			return nil, ErrInvalidKeySize
+		}
+
+		return key, nil
+	case KDFArgon2id:
+		err := params.validate()
+		if err != nil {
+			return nil, err
+		}
+
+		return argon2.IDKey(p, params.Salt, params.Time, params.Memory, params.Threads, aes256KeySize), nil
+	default:
+		return nil, ErrUnknownKDF
+	}
+}
+
+// validate checks that the argon2id params have a salt and cost parameters which can be derived with,
+// as they are read from the unauthenticated header of files and a huge memory or time would crash or hang the process.
+func (params KDFParams) validate() error {
+	if len(params.Salt) == 0 || params.Time == 0 || params.Time > MaxArgon2idTime ||
+		params.Memory == 0 || params.Memory > MaxArgon2idMemory || params.Threads == 0 {
+		return ErrInvalidKDFParams
+	}
+
+	return nil
+}
+
+// KeyCheck returns a value derived from the key "k" which can be stored in plain text
+// to check if a key is correct without decrypting any data.
+func KeyCheck(k []byte) ([]byte, error) {
+	return HmacHash([]byte(keyCheckLabel), k, nil)
+}
+
+// MarshalBinary encodes the params as kdf(1) | time(4) | memory(4) | threads(1) | salt length(1) | salt.
+func (params KDFParams) MarshalBinary() ([]byte, error) {
+	if len(params.Salt) > 0xff {
+		return nil, ErrInvalidKDFParams
+	}
+
+	b := make([]byte, kdfParamsHeaderSize, kdfParamsHeaderSize+len(params.Salt))
+	b[0] = byte(params.KDF)
+	binary.BigEndian.PutUint32(b[1:5], params.Time)
+	binary.BigEndian.PutUint32(b[5:9], params.Memory)
+	b[9] = params.Threads
+	b[10] = byte(len(params.Salt))
+
+	return append(b, params.Salt...), nil
+}
+
+// UnmarshalBinary decodes the params encoded by KDFParams.MarshalBinary.
+// Argon2id params whose cost parameters are out of range are rejected with ErrInvalidKDFParams.
+func (params *KDFParams) UnmarshalBinary(b []byte) error {
+	if len(b) < kdfParamsHeaderSize || len(b) != kdfParamsHeaderSize+int(b[10]) {
+		return ErrInvalidKDFParams
+	}
+
+	params.KDF = KDF(b[0])
+	params.Time = binary.BigEndian.Uint32(b[1:5])
+	params.Memory = binary.BigEndian.Uint32(b[5:9])
+	params.Threads = b[9]
+	params.Salt = append([]byte{}, b[kdfParamsHeaderSize:]...)
+
+	if params.KDF == KDFArgon2id {
+		return params.validate()
+	}
+
+	return nil
+}
@@ -0,0 +1,139 @@
+package crypto
+
+import (
+	_ "embed"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+)
+
+// effLargeWordlist is the EFF large wordlist for diceware passphrases with lines of the form "dice rolls<TAB>word".
+// It is from https://www.eff.org/files/2016/07/18/eff_large_wordlist.txt and is licensed under CC BY 3.0 US.
+//
+//go:embed eff_large_wordlist.txt
+var effLargeWordlist string
+
+var (
+	wordsOnce sync.Once
+	words     []string
+)
+
+// Wordlist returns the 7776 words of the EFF large wordlist used for passphrases.
+func Wordlist() []string {
+	wordsOnce.Do(func() {
+		lines := strings.Split(strings.TrimSpace(effLargeWordlist), "\n")
+		words = make([]string, 0, len(lines))
+
+		for _, line := range lines {
+			_, word, _ := strings.Cut(line, "\t")
+			words = append(words, word)
+		}
+	})
+
+	return append([]string{}, words...)
+}
+
+// PassphrasePolicy describes the passphrases generated by Generate.
+type PassphrasePolicy struct {
+	// Words is the number of words picked from the wordlist.
+	Words     int
+	Separator string
+	// Capitalize capitalizes the first letter of each word.
+	Capitalize bool
+	// Digit and Symbol insert a random digit or symbol at a random position of a random word,
+	// for sites which require them.
+	Digit  bool
+	Symbol bool
+}
+
+// DefaultPassphrasePolicy returns the policy of passphrases of "n" words separated by "-".
+func DefaultPassphrasePolicy(n int) PassphrasePolicy {
+	return PassphrasePolicy{
+		Words:     n,
+		Separator: "-",
+	}
+}
+
+// Entropy returns the entropy of the passphrases in bits assuming the wordlist and the policy are known.
+// The positions of the inserted digit and symbol within the word are not counted, so it is a lower bound.
+func (p PassphrasePolicy) Entropy() float64 {
+	bits := float64(p.Words) * math.Log2(float64(len(Wordlist())))
+
+	if p.Digit {
+		bits += math.Log2(float64(p.Words * len(Digits)))
+	}
+
+	if p.Symbol {
+		bits += math.Log2(float64(p.Words * len(Symbols)))
+	}
+
+	return bits
+}
+
+// insert inserts a random character of "chars" at a random position of a random word.
+func insert(passphrase [][]rune, chars string) error {
+	i, err := randInt(len(passphrase))
+	if err != nil {
+		return err
+	}
+
+	j, err := randInt(len(passphrase[i]) + 1)
+	if err != nil {
+		return err
+	}
+
+	r, err := pick([]rune(chars))
+	if err != nil {
+		return err
+	}
+
+	word := append([]rune{}, passphrase[i][:j]...)
+	word = append(word, r)
+	passphrase[i] = append(word, passphrase[i][j:]...)
+
+	return nil
+}
+
+// Generate generates a random passphrase with the policy, picking each word uniformly at random from the wordlist.
+func (p PassphrasePolicy) Generate() ([]byte, error) {
+	if p.Words <= 0 {
+		return nil, fmt.Errorf("%w: number of words must be positive", ErrInvalidPolicy)
+	}
+
+	list := Wordlist()
+	passphrase := make([][]rune, 0, p.Words)
+
+	for i := 0; i < p.Words; i++ {
+		j, err := randInt(len(list))
+		if err != nil {
+			return nil, err
+		}
+
+		word := []rune(list[j])
+		if p.Capitalize {
+			word[0] = []rune(strings.ToUpper(string(word[0])))[0]
+		}
+
+		passphrase = append(passphrase, word)
+	}
+
+	if p.Digit {
+		if err := insert(passphrase, Digits); err != nil {
+			return nil, err
+		}
+	}
+
+	if p.Symbol {
+		if err := insert(passphrase, Symbols); err != nil {
+			return nil, err
+		}
+	}
+
+	temp := make([]string, 0, len(passphrase))
+	for _, word := range passphrase {
+		temp = append(temp, string(word))
+	}
+
+	return []byte(strings.Join(temp, p.Separator)), nil
+}
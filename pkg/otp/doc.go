@@ -0,0 +1,7 @@
+/*
+Package otp implements one-time codes for two factor authentication:-
+Counter based codes using hotp of RFC 4226
+Time based codes using totp of RFC 6238
+Parsing of otpauth:// key uris and base32 secrets.
+*/
+package otp
@@ -0,0 +1,266 @@
+package otp
+
+import (
+	"crypto/hmac"
+	"crypto/sha1" //nolint
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Types of the keys.
+const (
+	// TypeTOTP is a time based key of RFC 6238.
+	TypeTOTP = "totp"
+	// TypeHOTP is a counter based key of RFC 4226.
+	TypeHOTP = "hotp"
+)
+
+// Algorithm is the hmac hash function used to generate the codes.
+type Algorithm string
+
+// Algorithms of the keys.
+const (
+	SHA1   Algorithm = "SHA1"
+	SHA256 Algorithm = "SHA256"
+	SHA512 Algorithm = "SHA512"
+)
+
+const (
+	// DefaultDigits is the default number of digits of the codes.
+	DefaultDigits = 6
+	// DefaultPeriod is the default time a totp code is valid for.
+	DefaultPeriod = 30 * time.Second
+	// MaxPeriod is the longest time a totp code can be valid for.
+	MaxPeriod = 24 * time.Hour
+	// uriScheme is the scheme of key uris like "otpauth://totp/Example:alice?secret=JBSWY3DPEHPK3PXP".
+	uriScheme = "otpauth"
+)
+
+var (
+	// ErrInvalidKey is the error thrown when the key is neither an otpauth uri nor a base32 secret.
+	ErrInvalidKey = errors.New("otp: invalid key, give an otpauth:// uri or a base32 secret")
+	// ErrUnsupportedAlgorithm is the error thrown when the hash function of the key is not supported.
+	ErrUnsupportedAlgorithm = errors.New("otp: unsupported algorithm")
+	// ErrInvalidDigits is the error thrown when the number of digits of the codes is not from 6 to 10.
+	ErrInvalidDigits = errors.New("otp: digits must be from 6 to 10")
+)
+
+// Key is the shared secret of the codes along with the parameters of how they are generated.
+type Key struct {
+	Type      string
+	Secret    []byte
+	Algorithm Algorithm
+	Digits    int
+	// Period is the time a totp code is valid for.
+	Period time.Duration
+	// Counter is the counter of the next hotp code.
+	Counter uint64
+	Issuer  string
+	Account string
+}
+
+// hash returns the hash function of the algorithm.
+func (a Algorithm) hash() (func() hash.Hash, error) {
+	switch a {
+	case SHA1:
+		return sha1.New, nil
+	case SHA256:
+		return sha256.New, nil
+	case SHA512:
+		return sha512.New, nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedAlgorithm, a)
+	}
+}
+
+// HOTP returns the code of the counter "c" for the secret "s" as in RFC 4226.
+func HOTP(s []byte, c uint64, digits int, a Algorithm) (string, error) {
+	if digits < 6 || digits > 10 {
+		return "", ErrInvalidDigits
+	}
+
+	h, err := a.hash()
+	if err != nil {
+		return "", err
+	}
+
+	msg := make([]byte, 8)
+	binary.BigEndian.PutUint64(msg, c)
+
+	mac := hmac.New(h, s)
+	mac.Write(msg)
+	sum := mac.Sum(nil)
+
+	// dynamic truncation takes 31 bits at the offset given by the last 4 bits.
+	offset := sum[len(sum)-1] & 0x0f
+	code := uint64(binary.BigEndian.Uint32(sum[offset:]) & 0x7fffffff)
+
+	mod := uint64(1)
+	for i := 0; i < digits; i++ {
+		mod *= 10
+	}
+
+	return fmt.Sprintf("%0*d", digits, code%mod), nil
+}
+
+// TOTP returns the code of the time "t" for the secret "s" as in RFC 6238,
+// where the counter is the number of periods since the unix epoch.
+func TOTP(s []byte, t time.Time, period time.Duration, digits int, a Algorithm) (string, error) {
+	if period < time.Second {
+		return "", fmt.Errorf("%w: period must be at least a second", ErrInvalidKey)
+	}
+
+	return HOTP(s, uint64(t.Unix()/int64(period/time.Second)), digits, a)
+}
+
+// decodeSecret decodes a base32 secret ignoring the case, spaces and padding as they are often shown that way.
+func decodeSecret(s string) ([]byte, error) {
+	s = strings.ToUpper(strings.Join(strings.Fields(s), ""))
+	s = strings.TrimRight(s, "=")
+
+	b, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(s)
+	if err != nil || len(b) == 0 {
+		return nil, ErrInvalidKey
+	}
+
+	return b, nil
+}
+
+// Parse parses an otpauth uri like "otpauth://totp/Example:alice?secret=JBSWY3DPEHPK3PXP&issuer=Example"
+// or a bare base32 secret, which is a totp key with the default parameters.
+func Parse(s string) (Key, error) {
+	k := Key{
+		Type:      TypeTOTP,
+		Algorithm: SHA1,
+		Digits:    DefaultDigits,
+		Period:    DefaultPeriod,
+	}
+
+	if !strings.HasPrefix(strings.ToLower(s), uriScheme+"://") {
+		secret, err := decodeSecret(s)
+		if err != nil {
+			return Key{}, err
+		}
+
+		k.Secret = secret
+
+		return k, nil
+	}
+
+	u, err := url.Parse(s)
+	if err != nil {
+		return Key{}, fmt.Errorf("%w: %s", ErrInvalidKey, err.Error())
+	}
+
+	k.Type = strings.ToLower(u.Host)
+	if k.Type != TypeTOTP && k.Type != TypeHOTP {
+		return Key{}, fmt.Errorf("%w: unknown type %q", ErrInvalidKey, u.Host)
+	}
+
+	label := strings.TrimPrefix(u.Path, "/")
+	if issuer, account, ok := strings.Cut(label, ":"); ok {
+		k.Issuer, k.Account = issuer, strings.TrimSpace(account)
+	} else {
+		k.Account = label
+	}
+
+	q := u.Query()
+
+	k.Secret, err = decodeSecret(q.Get("secret"))
+	if err != nil {
+		return Key{}, err
+	}
+
+	if issuer := q.Get("issuer"); issuer != "" {
+		k.Issuer = issuer
+	}
+
+	if a := q.Get("algorithm"); a != "" {
+		k.Algorithm = Algorithm(strings.ToUpper(a))
+		if _, err := k.Algorithm.hash(); err != nil {
+			return Key{}, err
+		}
+	}
+
+	if d := q.Get("digits"); d != "" {
+		k.Digits, err = strconv.Atoi(d)
+		if err != nil || k.Digits < 6 || k.Digits > 10 {
+			return Key{}, ErrInvalidDigits
+		}
+	}
+
+	// hotp keys don't use the period, so it is not checked for them.
+	if p := q.Get("period"); p != "" && k.Type == TypeTOTP {
+		period, err := strconv.Atoi(p)
+		if err != nil || period <= 0 || period > int(MaxPeriod/time.Second) {
+			return Key{}, fmt.Errorf("%w: invalid period %q, it must be from 1 to %d seconds", ErrInvalidKey, p, int(MaxPeriod/time.Second))
+		}
+
+		k.Period = time.Duration(period) * time.Second
+	}
+
+	if c := q.Get("counter"); c != "" {
+		k.Counter, err = strconv.ParseUint(c, 10, 64)
+		if err != nil {
+			return Key{}, fmt.Errorf("%w: invalid counter %q", ErrInvalidKey, c)
+		}
+	}
+
+	return k, nil
+}
+
+// URI returns the otpauth uri of the key.
+func (k Key) URI() string {
+	label := k.Account
+	if k.Issuer != "" {
+		label = k.Issuer + ":" + k.Account
+	}
+
+	q := url.Values{}
+	q.Set("secret", base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(k.Secret))
+	q.Set("algorithm", string(k.Algorithm))
+	q.Set("digits", strconv.Itoa(k.Digits))
+
+	if k.Issuer != "" {
+		q.Set("issuer", k.Issuer)
+	}
+
+	if k.Type == TypeHOTP {
+		q.Set("counter", strconv.FormatUint(k.Counter, 10))
+	} else {
+		q.Set("period", strconv.Itoa(int(k.Period/time.Second)))
+	}
+
+	u := url.URL{Scheme: uriScheme, Host: k.Type, Path: "/" + label, RawQuery: q.Encode()}
+
+	return u.String()
+}
+
+// Code returns the code of the key at the time "t" along with the time it is valid for.
+// Hotp codes don't expire and are generated with the counter of the key, which has to be incremented after.
+func (k Key) Code(t time.Time) (string, time.Duration, error) {
+	if k.Type == TypeHOTP {
+		code, err := HOTP(k.Secret, k.Counter, k.Digits, k.Algorithm)
+
+		return code, 0, err
+	}
+
+	code, err := TOTP(k.Secret, t, k.Period, k.Digits, k.Algorithm)
+	if err != nil {
+		return "", 0, err
+	}
+
+	period := int64(k.Period / time.Second)
+	remaining := time.Duration(period-t.Unix()%period) * time.Second
+
+	return code, remaining, nil
+}
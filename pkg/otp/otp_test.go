@@ -0,0 +1,194 @@
+package otp_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/231tr0n/vault/pkg/otp"
+)
+
+func failTestCase(t *testing.T, i, o, w any) {
+	t.Helper()
+	t.Error("Input:", i, "|", "Output:", o, "|", "Want:", w)
+}
+
+func TestHOTP(t *testing.T) {
+	t.Parallel()
+
+	// test vectors of RFC 4226 appendix D.
+	secret := []byte("12345678901234567890")
+
+	tests := []string{
+		"755224", "287082", "359152", "969429", "338314",
+		"254676", "287922", "162583", "399871", "520489",
+	}
+
+	for i, test := range tests {
+		t.Log(i, test)
+		out, err := otp.HOTP(secret, uint64(i), 6, otp.SHA1)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if out != test {
+			failTestCase(t, i, out, test)
+		}
+	}
+}
+
+func TestTOTP(t *testing.T) {
+	t.Parallel()
+
+	// test vectors of RFC 6238 appendix B.
+	secrets := map[otp.Algorithm][]byte{
+		otp.SHA1:   []byte(strings.Repeat("1234567890", 2)),
+		otp.SHA256: []byte(strings.Repeat("1234567890", 4)[:32]),
+		otp.SHA512: []byte(strings.Repeat("1234567890", 7)[:64]),
+	}
+
+	type test struct {
+		time  int64
+		codes map[otp.Algorithm]string
+	}
+
+	tests := []test{
+		{59, map[otp.Algorithm]string{otp.SHA1: "94287082", otp.SHA256: "46119246", otp.SHA512: "90693936"}},
+		{1111111109, map[otp.Algorithm]string{otp.SHA1: "07081804", otp.SHA256: "68084774", otp.SHA512: "25091201"}},
+		{1111111111, map[otp.Algorithm]string{otp.SHA1: "14050471", otp.SHA256: "67062674", otp.SHA512: "99943326"}},
+		{1234567890, map[otp.Algorithm]string{otp.SHA1: "89005924", otp.SHA256: "91819424", otp.SHA512: "93441116"}},
+		{2000000000, map[otp.Algorithm]string{otp.SHA1: "69279037", otp.SHA256: "90698825", otp.SHA512: "38618901"}},
+		{20000000000, map[otp.Algorithm]string{otp.SHA1: "65353130", otp.SHA256: "77737706", otp.SHA512: "47863826"}},
+	}
+
+	for _, test := range tests {
+		t.Log(test)
+
+		for a, code := range test.codes {
+			out, err := otp.TOTP(secrets[a], time.Unix(test.time, 0), otp.DefaultPeriod, 8, a)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if out != code {
+				failTestCase(t, []any{test.time, a}, out, code)
+			}
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	type test struct {
+		key  string
+		want otp.Key
+	}
+
+	tests := []test{
+		{
+			key: "JBSWY3DPEHPK3PXP",
+			want: otp.Key{
+				Type: otp.TypeTOTP, Secret: []byte("Hello!\xde\xad\xbe\xef"), Algorithm: otp.SHA1,
+				Digits: 6, Period: 30 * time.Second,
+			},
+		},
+		{
+			key: "jbsw y3dp ehpk 3pxp",
+			want: otp.Key{
+				Type: otp.TypeTOTP, Secret: []byte("Hello!\xde\xad\xbe\xef"), Algorithm: otp.SHA1,
+				Digits: 6, Period: 30 * time.Second,
+			},
+		},
+		{
+			key: "otpauth://totp/Example:alice@google.com?secret=JBSWY3DPEHPK3PXP&issuer=Example&algorithm=SHA256&digits=8&period=60",
+			want: otp.Key{
+				Type: otp.TypeTOTP, Secret: []byte("Hello!\xde\xad\xbe\xef"), Algorithm: otp.SHA256,
+				Digits: 8, Period: time.Minute, Issuer: "Example", Account: "alice@google.com",
+			},
+		},
+		{
+			key: "otpauth://hotp/alice?secret=JBSWY3DPEHPK3PXP&counter=7",
+			want: otp.Key{
+				Type: otp.TypeHOTP, Secret: []byte("Hello!\xde\xad\xbe\xef"), Algorithm: otp.SHA1,
+				Digits: 6, Period: 30 * time.Second, Counter: 7, Account: "alice",
+			},
+		},
+		{
+			key: "otpauth://hotp/alice?secret=JBSWY3DPEHPK3PXP&counter=7&period=99999999999999",
+			want: otp.Key{
+				Type: otp.TypeHOTP, Secret: []byte("Hello!\xde\xad\xbe\xef"), Algorithm: otp.SHA1,
+				Digits: 6, Period: 30 * time.Second, Counter: 7, Account: "alice",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Log(test)
+		out, err := otp.Parse(test.key)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if out.Type != test.want.Type || string(out.Secret) != string(test.want.Secret) ||
+			out.Algorithm != test.want.Algorithm || out.Digits != test.want.Digits || out.Period != test.want.Period ||
+			out.Counter != test.want.Counter || out.Issuer != test.want.Issuer || out.Account != test.want.Account {
+			failTestCase(t, test.key, out, test.want)
+		}
+
+		// the uri of the key parses to the same key.
+		again, err := otp.Parse(out.URI())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if again.URI() != out.URI() {
+			failTestCase(t, out.URI(), again.URI(), out.URI())
+		}
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]error{
+		"":                     otp.ErrInvalidKey,
+		"not base32!":          otp.ErrInvalidKey,
+		"otpauth://totp/alice": otp.ErrInvalidKey,
+		"otpauth://motp/alice?secret=JBSWY3DPEHPK3PXP":                       otp.ErrInvalidKey,
+		"otpauth://totp/alice?secret=JBSWY3DPEHPK3PXP&algorithm=MD5":         otp.ErrUnsupportedAlgorithm,
+		"otpauth://totp/alice?secret=JBSWY3DPEHPK3PXP&digits=4":              otp.ErrInvalidDigits,
+		"otpauth://totp/alice?secret=JBSWY3DPEHPK3PXP&period=0":              otp.ErrInvalidKey,
+		"otpauth://totp/alice?secret=JBSWY3DPEHPK3PXP&period=86401":          otp.ErrInvalidKey,
+		"otpauth://totp/alice?secret=JBSWY3DPEHPK3PXP&period=99999999999999": otp.ErrInvalidKey,
+		"otpauth://hotp/alice?secret=JBSWY3DPEHPK3PXP&counter=-1":            otp.ErrInvalidKey,
+	}
+
+	for key, want := range tests {
+		t.Log(key)
+		_, err := otp.Parse(key)
+
+		if !errors.Is(err, want) {
+			failTestCase(t, key, err, want)
+		}
+	}
+}
+
+func TestCode(t *testing.T) {
+	t.Parallel()
+
+	k, err := otp.Parse("GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	code, remaining, err := k.Code(time.Unix(59, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if code != "287082" || remaining != time.Second {
+		failTestCase(t, 59, []any{code, remaining}, []any{"287082", time.Second})
+	}
+}
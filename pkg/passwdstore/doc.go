@@ -1,6 +1,13 @@
 /*
 Package passwdstore implements basic functionality for storing key value pairs.
 It does this with proper encryption and hashing mechanism in a file securely.
-Also note that this package only works after you run the Init function which creates the password store file.
+The file starts with a versioned header describing the cipher and key derivation used,
+and files written by older versions are migrated to the current format on first unlock.
+Use the Open function to get a Vault handle for a password store file. Multiple vaults can be open at once.
+Each Vault method derives the key from the password again, so use Vault.Unlock to get a Session
+for many operations which are written to the file together with Session.Commit,
+or Vault.Update to run them while holding the lock on the file so that concurrent writers wait for each other.
+The package level functions operate on a single vault and only work after you run the Init function
+which creates the password store file.
 */
 package passwdstore
@@ -0,0 +1,110 @@
+package passwdstore
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Entry is a password stored in the vault along with its metadata.
+// Binary values like certificates or keys are stored in Data, which is base64 encoded in the json, instead of Password.
+// OTP is the key of the one-time codes of the entry, an otpauth uri or a base32 secret.
+// History holds the previous values of the entry, the most recent first, and is kept by the store.
+type Entry struct {
+	Password string            `json:"password"`
+	Data     []byte            `json:"data,omitempty"`
+	Username string            `json:"username,omitempty"`
+	URL      string            `json:"url,omitempty"`
+	Notes    string            `json:"notes,omitempty"`
+	Tags     []string          `json:"tags,omitempty"`
+	Fields   map[string]string `json:"fields,omitempty"`
+	OTP      string            `json:"otp,omitempty"`
+	History  []Version         `json:"history,omitempty"`
+	Created  time.Time         `json:"created"`
+	Modified time.Time         `json:"modified"`
+}
+
+// Version is a previous value of an entry.
+type Version struct {
+	Password string `json:"password"`
+	Data     []byte `json:"data,omitempty"`
+	// Replaced is the time the value was replaced by a newer one.
+	Replaced time.Time `json:"replaced"`
+}
+
+// Value returns the binary value of the version if it has one, else the password.
+func (v Version) Value() []byte {
+	if v.Data != nil {
+		return append([]byte{}, v.Data...)
+	}
+
+	return []byte(v.Password)
+}
+
+// UnmarshalJSON unmarshals the entry from json.
+// Older versions stored only the password as a json string, which is migrated to an entry with just the password.
+func (e *Entry) UnmarshalJSON(b []byte) error {
+	var passwd string
+	if err := json.Unmarshal(b, &passwd); err == nil {
+		*e = Entry{Password: passwd}
+
+		return nil
+	}
+
+	// entry has the same fields as Entry without the UnmarshalJSON method.
+	type entry Entry
+
+	var temp entry
+
+	err := json.Unmarshal(b, &temp)
+	if err != nil {
+		return wrap(err)
+	}
+
+	*e = Entry(temp)
+
+	return nil
+}
+
+// Value returns the binary value of the entry if it has one, else the password.
+func (e Entry) Value() []byte {
+	if e.Data != nil {
+		return append([]byte{}, e.Data...)
+	}
+
+	return []byte(e.Password)
+}
+
+// clone returns a deep copy of the entry so that the data, tags, fields and history are not shared.
+func (e Entry) clone() Entry {
+	if e.Data != nil {
+		e.Data = append([]byte{}, e.Data...)
+	}
+
+	if e.Tags != nil {
+		e.Tags = append([]string{}, e.Tags...)
+	}
+
+	if e.Fields != nil {
+		fields := make(map[string]string, len(e.Fields))
+		for k, v := range e.Fields {
+			fields[k] = v
+		}
+
+		e.Fields = fields
+	}
+
+	if e.History != nil {
+		history := make([]Version, len(e.History))
+		for i, v := range e.History {
+			if v.Data != nil {
+				v.Data = append([]byte{}, v.Data...)
+			}
+
+			history[i] = v
+		}
+
+		e.History = history
+	}
+
+	return e
+}
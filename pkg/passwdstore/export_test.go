@@ -0,0 +1,60 @@
+package passwdstore
+
+import (
+	"errors"
+	"os"
+
+	"github.com/231tr0n/vault/pkg/crypto"
+)
+
+// DecryptFile returns the decrypted json stored in the password store file.
+func DecryptFile(p []byte) ([]byte, error) {
+	data, err := os.ReadFile(defaultVault.path)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := parseFileData(data)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := crypto.DeriveKey(p, f.Header.KDF)
+	if err != nil {
+		return nil, err
+	}
+
+	return f.decrypt(key)
+}
+
+// ErrWriteFailed is the error returned by writes made to fail with FailWrites.
+var ErrWriteFailed = errors.New("passwdstore: simulated write failure")
+
+// FailWrites makes every write of the password store file fail after writing half of the data.
+// The returned function restores normal writes.
+func FailWrites() func() {
+	orig := writeTempFile
+	writeTempFile = func(f *os.File, data []byte) error {
+		_, err := f.Write(data[:len(data)/2])
+		if err != nil {
+			return err
+		}
+
+		return ErrWriteFailed
+	}
+
+	return func() {
+		writeTempFile = orig
+	}
+}
+
+// LockVault acquires the lock on the password store file as another process would.
+// The returned function releases it.
+func LockVault() (func() error, error) {
+	l, err := lockFile(defaultVault.path, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	return l.unlock, nil
+}
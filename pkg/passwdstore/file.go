@@ -0,0 +1,114 @@
+package passwdstore
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+const (
+	// dirPerm is the permission of the directory holding the password store file.
+	dirPerm = 0o700
+	// filePerm is the permission of the password store file.
+	filePerm = 0o600
+	// otherPerm are the permission bits for users other than the owner.
+	otherPerm = 0o077
+)
+
+// writeTempFile writes the data to the temporary file.
+// It is replaced in tests to simulate failed writes.
+var writeTempFile = func(f *os.File, data []byte) error {
+	_, err := f.Write(data)
+
+	return err
+}
+
+// writeFile atomically replaces the file "f" with "data".
+// The data is written to a temporary file in the same directory which is synced to disk
+// and then renamed over "f", so a crash or a failed write never leaves "f" partially written.
+func writeFile(f string, data []byte) error {
+	dir := filepath.Dir(f)
+
+	// The temporary file is created with filePerm permissions.
+	tmp, err := os.CreateTemp(dir, filepath.Base(f)+".tmp*")
+	if err != nil {
+		return wrap(err)
+	}
+
+	renamed := false
+
+	defer func() {
+		if !renamed {
+			_ = tmp.Close()
+			_ = os.Remove(tmp.Name())
+		}
+	}()
+
+	err = writeTempFile(tmp, data)
+	if err != nil {
+		return wrap(err)
+	}
+
+	err = tmp.Sync()
+	if err != nil {
+		return wrap(err)
+	}
+
+	err = tmp.Close()
+	if err != nil {
+		return wrap(err)
+	}
+
+	err = os.Rename(tmp.Name(), f)
+	if err != nil {
+		return wrap(err)
+	}
+
+	renamed = true
+
+	return syncDir(dir)
+}
+
+// syncDir syncs the directory "d" so that a rename in it is persisted to disk.
+func syncDir(d string) error {
+	// Directories can not be synced on windows.
+	if runtime.GOOS == "windows" {
+		return nil
+	}
+
+	dir, err := os.Open(d)
+	if err != nil {
+		return wrap(err)
+	}
+
+	err = dir.Sync()
+	if err != nil {
+		_ = dir.Close()
+
+		return wrap(err)
+	}
+
+	return wrap(dir.Close())
+}
+
+// checkPermissions checks that the file "f" and its directory are not accessible by other users.
+func checkPermissions(f string) error {
+	// Windows does not have unix permission bits.
+	if runtime.GOOS == "windows" {
+		return nil
+	}
+
+	for _, p := range []string{filepath.Dir(f), f} {
+		stat, err := os.Stat(p)
+		if err != nil {
+			return wrap(err)
+		}
+
+		if stat.Mode().Perm()&otherPerm != 0 {
+			return fmt.Errorf("%w: %s has permissions %#o", ErrInsecurePermissions, p, stat.Mode().Perm())
+		}
+	}
+
+	return nil
+}
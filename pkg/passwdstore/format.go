@@ -0,0 +1,237 @@
+package passwdstore
+
+import (
+	"bytes"
+	"encoding/hex"
+
+	"github.com/231tr0n/vault/pkg/crypto"
+)
+
+// The password store file is made of '.' separated components.
+// The current format is magic.hex(header).hex(ciphertext) where the header is
+// version(1) | cipher(1) | key check(64) | kdf params as encoded by crypto.KDFParams.MarshalBinary.
+// The magic and header are passed as additional data to aes gcm so any change to them
+// or to the ciphertext is detected when decrypting. The key check value tells a wrong password
+// apart from such tampering, except that changes to the kdf params or the key check value itself
+// can not be told apart from a wrong password.
+// Files written by older versions of vault in the legacy format are still read
+// and are migrated to the current format on first unlock.
+const (
+	// magic is the first component of every versioned password store file.
+	magic = "vault"
+
+	// formatVersionLegacy is the format hex(ciphertext).hex(sha256) where
+	// the key is the password padded with '0' bytes.
+	formatVersionLegacy = 0
+	// formatVersion is the current format written by this package.
+	formatVersion = 1
+
+	legacyFileComponents = 2
+	fileComponents       = 3
+
+	headerSize = 2
+	// keyCheckSize is the size of the hex encoded key check value returned by crypto.KeyCheck.
+	keyCheckSize = 64
+)
+
+// cipherID identifies the cipher used to encrypt the password store.
+type cipherID uint8
+
+const (
+	cipherAES256GCM cipherID = iota + 1
+)
+
+// fileHeader describes how the rest of the password store file is encrypted.
+type fileHeader struct {
+	Version  uint8
+	Cipher   cipherID
+	KeyCheck []byte
+	KDF      crypto.KDFParams
+}
+
+// fileData is the parsed password store file.
+type fileData struct {
+	Header fileHeader
+	// AD is the part of the file before the ciphertext which is authenticated along with it.
+	AD []byte
+	// Enc is the hex encoded ciphertext.
+	Enc []byte
+	// Sum is the hex encoded sha256 checksum of the ciphertext of the legacy format.
+	Sum []byte
+}
+
+// newFileHeader returns the header for the current format with the kdf params "params".
+// The key check value is filled in by newFileData once the key is derived.
+func newFileHeader(params crypto.KDFParams) fileHeader {
+	return fileHeader{
+		Version: formatVersion,
+		Cipher:  cipherAES256GCM,
+		KDF:     params,
+	}
+}
+
+// MarshalBinary encodes the header as version(1) | cipher(1) | key check(64) | kdf params.
+func (h fileHeader) MarshalBinary() ([]byte, error) {
+	if len(h.KeyCheck) != keyCheckSize {
+		return nil, ErrPasswdFileManuallyEdited
+	}
+
+	params, err := h.KDF.MarshalBinary()
+	if err != nil {
+		return nil, wrap(err)
+	}
+
+	b := append([]byte{h.Version, byte(h.Cipher)}, h.KeyCheck...)
+
+	return append(b, params...), nil
+}
+
+// UnmarshalBinary decodes the header encoded by fileHeader.MarshalBinary.
+func (h *fileHeader) UnmarshalBinary(b []byte) error {
+	if len(b) < headerSize {
+		return ErrPasswdFileManuallyEdited
+	}
+
+	h.Version = b[0]
+	h.Cipher = cipherID(b[1])
+	b = b[headerSize:]
+
+	if h.Version != formatVersion {
+		return ErrUnsupportedFileVersion
+	}
+
+	if len(b) < keyCheckSize {
+		return ErrPasswdFileManuallyEdited
+	}
+
+	h.KeyCheck = append([]byte{}, b[:keyCheckSize]...)
+
+	err := h.KDF.UnmarshalBinary(b[keyCheckSize:])
+	if err != nil {
+		return ErrPasswdFileManuallyEdited
+	}
+
+	return nil
+}
+
+// parseFileData parses the password store file of the current or the legacy format.
+func parseFileData(data []byte) (fileData, error) {
+	var f fileData
+
+	pData := bytes.Split(data, []byte{'.'})
+
+	switch {
+	case len(pData) > 0 && string(pData[0]) == magic:
+		if len(pData) != fileComponents {
+			return f, ErrPasswdFileManuallyEdited
+		}
+
+		b, err := hex.DecodeString(string(pData[1]))
+		if err != nil {
+			return f, ErrPasswdFileManuallyEdited
+		}
+
+		err = f.Header.UnmarshalBinary(b)
+		if err != nil {
+			return f, err
+		}
+
+		if f.Header.Cipher != cipherAES256GCM {
+			return f, ErrUnsupportedFileVersion
+		}
+
+		f.AD = bytes.Join(pData[:2], []byte{'.'})
+		f.Enc = pData[2]
+
+		return f, nil
+	case len(pData) == legacyFileComponents:
+		// The legacy format has no additional data and ends with a checksum of the ciphertext.
+		f.Header = fileHeader{
+			Version: formatVersionLegacy,
+			Cipher:  cipherAES256GCM,
+			KDF:     crypto.KDFParams{KDF: crypto.KDFLegacy},
+		}
+		f.Enc, f.Sum = pData[0], pData[1]
+
+		return f, nil
+	default:
+		return f, ErrPasswdFileManuallyEdited
+	}
+}
+
+// verifyChecksum checks the unkeyed checksum of the legacy format.
+func (f fileData) verifyChecksum() error {
+	if f.Sum == nil {
+		return nil
+	}
+
+	h, err := crypto.Hash(f.Enc, nil)
+	if err != nil {
+		return wrap(err)
+	}
+
+	if !crypto.Verify(h, f.Sum) {
+		return ErrPasswdFileIntegrityFail
+	}
+
+	return nil
+}
+
+// newFileData encrypts "s" with the key "k" and builds the password store file in the current format.
+func newFileData(h fileHeader, s, k []byte) (fileData, error) {
+	kc, err := crypto.KeyCheck(k)
+	if err != nil {
+		return fileData{}, wrap(err)
+	}
+
+	h.KeyCheck = kc
+
+	b, err := h.MarshalBinary()
+	if err != nil {
+		return fileData{}, err
+	}
+
+	ad := bytes.Join([][]byte{[]byte(magic), []byte(hex.EncodeToString(b))}, []byte{'.'})
+
+	enc, err := crypto.Encrypt(s, k, ad)
+	if err != nil {
+		return fileData{}, wrap(err)
+	}
+
+	return fileData{
+		Header: h,
+		AD:     ad,
+		Enc:    enc,
+	}, nil
+}
+
+// decrypt decrypts the ciphertext with the key "k".
+func (f fileData) decrypt(k []byte) ([]byte, error) {
+	if f.Header.Version == formatVersion {
+		kc, err := crypto.KeyCheck(k)
+		if err != nil {
+			return nil, wrap(err)
+		}
+
+		if !crypto.HmacVerify(kc, f.Header.KeyCheck) {
+			return nil, wrap(crypto.ErrWrongPasswd)
+		}
+	}
+
+	s, err := crypto.Decrypt(f.Enc, k, f.AD)
+	if err != nil {
+		// The key is known to be right so the header or ciphertext has been tampered with.
+		if f.Header.Version == formatVersion {
+			return nil, ErrPasswdFileIntegrityFail
+		}
+
+		return nil, wrap(err)
+	}
+
+	return s, nil
+}
+
+// Bytes returns the contents of the password store file.
+func (f fileData) Bytes() []byte {
+	return bytes.Join([][]byte{f.AD, f.Enc}, []byte{'.'})
+}
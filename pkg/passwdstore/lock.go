@@ -0,0 +1,64 @@
+package passwdstore
+
+import (
+	"os"
+	"time"
+)
+
+const (
+	// DefaultLockTimeout is the default time to wait for another process to release the vault.
+	DefaultLockTimeout = 5 * time.Second
+	// lockRetryInterval is the time to wait before trying to acquire the lock again.
+	lockRetryInterval = 50 * time.Millisecond
+	// lockFileSuffix is appended to the password store file path to get the lock file path.
+	lockFileSuffix = ".lock"
+)
+
+// fileLock is an advisory lock held on the lock file of the password store.
+// The lock is held on a separate file because the password store file is replaced on every write.
+type fileLock struct {
+	f *os.File
+}
+
+// lockFile acquires the lock for the file "f", waiting for at most "timeout" for another process to release it.
+func lockFile(f string, timeout time.Duration) (*fileLock, error) {
+	file, err := os.OpenFile(f+lockFileSuffix, os.O_RDWR|os.O_CREATE, filePerm)
+	if err != nil {
+		return nil, wrap(err)
+	}
+
+	deadline := time.Now().Add(timeout)
+
+	for {
+		ok, err := tryLock(file)
+		if err != nil {
+			_ = file.Close()
+
+			return nil, wrap(err)
+		}
+
+		if ok {
+			return &fileLock{f: file}, nil
+		}
+
+		if time.Now().After(deadline) {
+			_ = file.Close()
+
+			return nil, ErrVaultLocked
+		}
+
+		time.Sleep(lockRetryInterval)
+	}
+}
+
+// unlock releases the lock.
+func (l *fileLock) unlock() error {
+	err := unlock(l.f)
+	if err != nil {
+		_ = l.f.Close()
+
+		return wrap(err)
+	}
+
+	return wrap(l.f.Close())
+}
@@ -0,0 +1,17 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd || dragonfly || windows)
+
+package passwdstore
+
+import (
+	"os"
+)
+
+// tryLock always succeeds as file locking is not supported on this platform.
+func tryLock(_ *os.File) (bool, error) {
+	return true, nil
+}
+
+// unlock does nothing as file locking is not supported on this platform.
+func unlock(_ *os.File) error {
+	return nil
+}
@@ -1,23 +1,18 @@
 package passwdstore
 
 import (
-	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"os"
-	"path/filepath"
-
-	"github.com/231tr0n/vault/pkg/crypto"
-)
-
-const (
-	fileComponents = 2
+	"time"
 )
 
 type passwdStore struct {
-	Passwd []byte            `json:"passwd"`
-	Store  map[string]string `json:"store"`
+	// Initialised is set once the vault password is set for the first time.
+	Initialised bool `json:"initialised"`
+	// Passwd is only read to migrate files which used to store the vault password. It is never written.
+	Passwd []byte           `json:"passwd,omitempty"`
+	Store  map[string]Entry `json:"store"`
 }
 
 func wrap(err error) error {
@@ -30,15 +25,31 @@ func wrap(err error) error {
 
 func newpasswdStore() passwdStore {
 	return passwdStore{
-		Passwd: []byte(""),
-		Store:  make(map[string]string),
+		Store: make(map[string]Entry),
 	}
 }
 
+// marshal marshals the store to json without the legacy password field.
+func (store passwdStore) marshal() ([]byte, error) {
+	store.Passwd = nil
+
+	s, err := json.Marshal(store)
+	if err != nil {
+		return nil, wrap(err)
+	}
+
+	return s, nil
+}
+
 var (
-	passwdStoreFilePath = ""
+	// defaultVault is the vault opened by Init which is used by the package level functions.
+	defaultVault *Vault
+	lockTimeout  = DefaultLockTimeout
+	// ErrNotInitialised is the error thrown when the package level functions
+	// are used before the passwdstore.Init function is run.
+	ErrNotInitialised = errors.New("passwdstore: password store not initialised")
 	// ErrFilePathNotAbsolute is the error thrown when
-	// the passwdstore.Init function does not get an absolute path as an argument.
+	// the passwdstore.Init or passwdstore.Open function does not get an absolute path as an argument.
 	ErrFilePathNotAbsolute = errors.New("passwdstore: given filepath not absolute")
 	// ErrPasswdFileManuallyEdited is the error thrown when
 	// the passwdstore file at $HOME/.vault/.passwdstore is not parsable as it is manually edited.
@@ -46,222 +57,149 @@ var (
 	// ErrPasswdFileIntegrityFail is the error thrown when
 	// the passwdstore file at $HOME/.vault/.passwdstore fails
 	// the integrity check which means that the contents are edited.
+	// The check is bound to the vault password, so it is only reported once the password is known to be right.
 	ErrPasswdFileIntegrityFail = errors.New("passwdstore: password file integrity fail")
 	// ErrVaultPasswdNotSet is the error thrown when the
 	// vault password is empty or not set. Use the passwdstore.ChangePasswd
 	// function to set the password initially as it is empty.
 	ErrVaultPasswdNotSet = errors.New("passwdstore: vault password not set")
+	// ErrUnsupportedFileVersion is the error thrown when the passwdstore file
+	// is written in a format version or with a cipher which is not supported by this version of vault.
+	ErrUnsupportedFileVersion = errors.New("passwdstore: unsupported password file version")
+	// ErrInsecurePermissions is the error thrown when the passwdstore file or its directory
+	// can be accessed by other users. Use the passwdstore.FixPermissions function to fix it.
+	ErrInsecurePermissions = errors.New("passwdstore: password file permissions are too open")
+	// ErrVaultLocked is the error thrown when another process holds the lock on the
+	// passwdstore file for longer than the lock timeout.
+	ErrVaultLocked = errors.New("passwdstore: vault is locked by another process")
+	// ErrVaultModified is the error thrown when a session is committed
+	// after the passwdstore file was changed by someone else since the session read it.
+	ErrVaultModified = errors.New("passwdstore: vault modified since it was unlocked")
+	// ErrEntryNotFound is the error thrown when there is no entry with the given key in the store.
+	// Use the passwdstore.Suggest function to find keys close to the given key.
+	ErrEntryNotFound = errors.New("passwdstore: entry not found")
+	// ErrEntryExists is the error thrown when an entry would be overwritten by renaming, copying or moving entries.
+	ErrEntryExists = errors.New("passwdstore: entry already exists")
+	// ErrInvalidKey is the error thrown when a key or folder is empty.
+	ErrInvalidKey = errors.New("passwdstore: invalid key")
+	// ErrVersionNotFound is the error thrown when the history of an entry has no version with the given number.
+	ErrVersionNotFound = errors.New("passwdstore: version not found in the history")
+	// ErrSessionLocked is the error thrown when a session is used after it is locked.
+	ErrSessionLocked = errors.New("passwdstore: session is locked")
 )
 
-// Init sets the given filepath for password store file.
+// Init opens the password store file at the given filepath for the package level functions.
+// It creates the file if it does not exist.
 func Init(f string) error {
-	if !filepath.IsAbs(f) {
-		return ErrFilePathNotAbsolute
-	}
-
-	if stat, err := os.Stat(f); err == nil {
-		if !stat.IsDir() {
-			passwdStoreFilePath = f
-
-			return nil
-		}
-	}
-
-	err := os.MkdirAll(filepath.Dir(f), os.ModePerm)
+	v, err := Open(f, &Options{LockTimeout: lockTimeout})
 	if err != nil {
-		return wrap(err)
+		return err
 	}
 
-	_, err = os.Create(f)
-	if err != nil {
-		return wrap(err)
-	}
-
-	passwdStoreFilePath = f
+	defaultVault = v
 
 	return nil
 }
 
-// decryptFileData decrypts the file, unmarshals the json to struct and returns it.
-func decryptFileData(p []byte) (passwdStore, error) {
-	_, err := os.Stat(passwdStoreFilePath)
-	if err != nil {
-		return newpasswdStore(), wrap(err)
-	}
-
-	data, err := os.ReadFile(passwdStoreFilePath)
-	if err != nil {
-		return newpasswdStore(), wrap(err)
-	}
-
-	if len(data) == 0 {
-		return newpasswdStore(), nil
-	}
-
-	pData := bytes.Split(data, []byte{'.'})
-	if len(pData) != fileComponents {
-		return newpasswdStore(), ErrPasswdFileManuallyEdited
-	}
-
-	h, err := crypto.Hash(pData[0], nil)
-	if err != nil {
-		return newpasswdStore(), wrap(err)
-	}
-
-	if !crypto.Verify(h, pData[1]) {
-		return newpasswdStore(), ErrPasswdFileIntegrityFail
-	}
-
-	s, err := crypto.Decrypt(pData[0], p)
-	if err != nil {
-		return newpasswdStore(), wrap(err)
-	}
-
-	store := newpasswdStore()
-	err = json.Unmarshal(s, &store)
-	if err != nil {
-		return newpasswdStore(), wrap(err)
+// SetLockTimeout sets the time the package level functions wait for another process
+// to release the vault before failing with ErrVaultLocked.
+func SetLockTimeout(d time.Duration) {
+	lockTimeout = d
+	if defaultVault != nil {
+		defaultVault.lockTimeout = d
 	}
-
-	return store, nil
 }
 
-// encryptFileData marshals the struct to json, encrypts it and stores the content in the file.
-func encryptFileData(store passwdStore, p []byte) error {
-	if string(store.Passwd) == "" {
-		return ErrVaultPasswdNotSet
-	}
-
-	store.Passwd = p
-	s, err := json.Marshal(store)
-	if err != nil {
-		return wrap(err)
-	}
-
-	enc, err := crypto.Encrypt(s, p)
-	if err != nil {
-		return wrap(err)
-	}
-
-	h, err := crypto.Hash(enc, nil)
-	if err != nil {
-		return wrap(err)
+// FixPermissions restricts the permissions of the password store file and its directory to the current user.
+func FixPermissions() error {
+	if defaultVault == nil {
+		return ErrNotInitialised
 	}
 
-	err = os.WriteFile(passwdStoreFilePath, bytes.Join([][]byte{enc, h}, []byte{'.'}), os.ModePerm)
-	if err != nil {
-		return wrap(err)
-	}
-
-	return nil
+	return defaultVault.FixPermissions()
 }
 
 // Get gets the key value pair from the store.
+// It fails with ErrEntryNotFound if there is no entry with the key "k".
 func Get(k string, p []byte) (string, error) {
-	store, err := decryptFileData(p)
-	if err != nil {
-		return "", wrap(err)
+	if defaultVault == nil {
+		return "", ErrNotInitialised
 	}
 
-	value := store.Store[k]
-
-	return value, nil
+	return defaultVault.Get(k, p)
 }
 
 // Put puts the key value pair in the store.
 func Put(k, v string, p []byte) error {
-	store, err := decryptFileData(p)
-	if err != nil {
-		return wrap(err)
-	}
-
-	store.Store[k] = v
-
-	err = encryptFileData(store, p)
-	if err != nil {
-		return wrap(err)
+	if defaultVault == nil {
+		return ErrNotInitialised
 	}
 
-	return nil
+	return defaultVault.Put(k, v, p)
 }
 
-// ListKeys lists all the keys in the store.
+// ListKeys lists all the keys in the store sorted.
 func ListKeys(p []byte) ([]string, error) {
-	store, err := decryptFileData(p)
-	if err != nil {
-		return nil, wrap(err)
+	if defaultVault == nil {
+		return nil, ErrNotInitialised
 	}
 
-	temp := make([]string, 0)
-	for i := range store.Store {
-		temp = append(temp, i)
-	}
-
-	return temp, nil
+	return defaultVault.ListKeys(p)
 }
 
 // ListEntries lists all the key value pairs in the store.
 func ListEntries(p []byte) ([][2]string, error) {
-	store, err := decryptFileData(p)
-	if err != nil {
-		return nil, wrap(err)
-	}
-
-	temp := make([][2]string, 0)
-	for i, j := range store.Store {
-		temp = append(temp, [2]string{i, j})
+	if defaultVault == nil {
+		return nil, ErrNotInitialised
 	}
 
-	return temp, nil
+	return defaultVault.ListEntries(p)
 }
 
 // Delete deletes the key value pair in the store.
+// It fails with ErrEntryNotFound if there is no entry with the key "k".
 func Delete(k string, p []byte) error {
-	store, err := decryptFileData(p)
-	if err != nil {
-		return wrap(err)
+	if defaultVault == nil {
+		return ErrNotInitialised
 	}
 
-	delete(store.Store, k)
+	return defaultVault.Delete(k, p)
+}
 
-	err = encryptFileData(store, p)
-	if err != nil {
-		return wrap(err)
+// Rename renames the entry "from" to "to" with a single write.
+// It fails with ErrEntryExists if there is an entry "to" unless "overwrite" is set.
+func Rename(from, to string, overwrite bool, p []byte) error {
+	if defaultVault == nil {
+		return ErrNotInitialised
 	}
 
-	return nil
+	return defaultVault.Rename(from, to, overwrite, p)
 }
 
-// Clear clears all the key value pairs in the store.
-func Clear(p []byte) error {
-	_, err := decryptFileData(p)
-	if err != nil {
-		return wrap(err)
+// Copy copies the entry "from" to a new entry "to" with a single write.
+// It fails with ErrEntryExists if there is an entry "to" unless "overwrite" is set.
+func Copy(from, to string, overwrite bool, p []byte) error {
+	if defaultVault == nil {
+		return ErrNotInitialised
 	}
 
-	empty := newpasswdStore()
-	empty.Passwd = p
-	err = encryptFileData(empty, p)
-	if err != nil {
-		return wrap(err)
+	return defaultVault.Copy(from, to, overwrite, p)
+}
+
+// Clear clears all the key value pairs in the store.
+func Clear(p []byte) error {
+	if defaultVault == nil {
+		return ErrNotInitialised
 	}
 
-	return nil
+	return defaultVault.Clear(p)
 }
 
 // ChangePasswd changes the password for the store.
 func ChangePasswd(np, op []byte) error {
-	store, err := decryptFileData(op)
-	if err != nil {
-		return wrap(err)
+	if defaultVault == nil {
+		return ErrNotInitialised
 	}
 
-	store.Passwd = np
-
-	err = encryptFileData(store, np)
-	if err != nil {
-		return wrap(err)
-	}
-
-	return nil
+	return defaultVault.ChangePasswd(np, op)
 }
@@ -1,10 +1,18 @@
 package passwdstore_test
 
 import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/231tr0n/vault/pkg/crypto"
 	"github.com/231tr0n/vault/pkg/passwdstore"
 )
 
@@ -209,12 +217,12 @@ func TestClear(t *testing.T) {
 		}
 
 		value, err = passwdstore.Get(test[0], passwd)
-		if err != nil {
-			t.Fatal(err)
+		if !errors.Is(err, passwdstore.ErrEntryNotFound) {
+			failTestCase(t, test, err, passwdstore.ErrEntryNotFound)
 		}
 
 		if value != "" {
-			failTestCase(t, test, value, test[1])
+			failTestCase(t, test, value, "")
 		}
 	}
 }
@@ -360,12 +368,1260 @@ func TestDelete(t *testing.T) {
 		}
 
 		value, err = passwdstore.Get(test[0], passwd)
+		if !errors.Is(err, passwdstore.ErrEntryNotFound) {
+			failTestCase(t, test, err, passwdstore.ErrEntryNotFound)
+		}
+
+		if value != "" {
+			failTestCase(t, test, value, "")
+		}
+
+		err = passwdstore.Delete(test[0], passwd)
+		if !errors.Is(err, passwdstore.ErrEntryNotFound) {
+			failTestCase(t, "delete again", err, passwdstore.ErrEntryNotFound)
+		}
+	}
+}
+
+func TestLegacyFile(t *testing.T) {
+	tempDir := t.TempDir()
+
+	passwdStoreFilePath := filepath.Join(tempDir, ".vault", ".passwdstore")
+
+	passwd := []byte("secret")
+
+	data, err := json.Marshal(map[string]any{
+		"passwd": passwd,
+		"store": map[string]string{
+			"hi": "how are you",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key, err := crypto.DeriveKey(passwd, crypto.KDFParams{KDF: crypto.KDFLegacy})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	enc, err := crypto.Encrypt(data, key, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h, err := crypto.Hash(enc, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = passwdstore.Init(passwdStoreFilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = os.WriteFile(passwdStoreFilePath, bytes.Join([][]byte{enc, h}, []byte{'.'}), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// legacy keys are the password padded to 32 bytes, so longer passwords are wrong too.
+	for _, test := range []string{"wrong", strings.Repeat("wrong", 8)} {
+		t.Log(test)
+
+		_, err = passwdstore.Get("hi", []byte(test))
+		if !errors.Is(err, crypto.ErrWrongPasswd) {
+			failTestCase(t, test, err, crypto.ErrWrongPasswd)
+		}
+	}
+
+	value, err := passwdstore.Get("hi", passwd)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if value != "how are you" {
+		failTestCase(t, "hi", value, "how are you")
+	}
+
+	data, err = os.ReadFile(passwdStoreFilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.HasPrefix(data, []byte("vault.")) {
+		failTestCase(t, "legacy file", string(data), "file migrated to the current format")
+	}
+
+	v, err := passwdstore.Open(passwdStoreFilePath, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e, err := v.GetEntry("hi", passwd)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if e.Created.IsZero() || e.Modified.IsZero() {
+		failTestCase(t, "legacy file", e, "entries dated to the migration")
+	}
+
+	data, err = passwdstore.DecryptFile(passwd)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if bytes.Contains(data, []byte(`"passwd"`)) || !bytes.Contains(data, []byte(`"initialised":true`)) {
+		failTestCase(t, "legacy file", string(data), "vault password removed from the file")
+	}
+
+	err = passwdstore.Put("hello", "fine", passwd)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	value, err = passwdstore.Get("hi", passwd)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if value != "how are you" {
+		failTestCase(t, "hi", value, "how are you")
+	}
+}
+
+func TestUnsupportedFileVersion(t *testing.T) {
+	tempDir := t.TempDir()
+
+	passwdStoreFilePath := filepath.Join(tempDir, ".vault", ".passwdstore")
+
+	passwd := []byte("secret")
+
+	err := passwdstore.Init(passwdStoreFilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = passwdstore.ChangePasswd(passwd, []byte(""))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(passwdStoreFilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pData := bytes.Split(data, []byte{'.'})
+	header, err := hex.DecodeString(string(pData[1]))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	header[0]++
+	pData[1] = []byte(hex.EncodeToString(header))
+
+	err = os.WriteFile(passwdStoreFilePath, bytes.Join(pData, []byte{'.'}), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = passwdstore.ListKeys(passwd)
+	if !errors.Is(err, passwdstore.ErrUnsupportedFileVersion) {
+		failTestCase(t, "file with newer version", err, passwdstore.ErrUnsupportedFileVersion)
+	}
+}
+
+func TestTamperedFile(t *testing.T) {
+	tempDir := t.TempDir()
+
+	passwdStoreFilePath := filepath.Join(tempDir, ".vault", ".passwdstore")
+
+	passwd := []byte("secret")
+
+	err := passwdstore.Init(passwdStoreFilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = passwdstore.ChangePasswd(passwd, []byte(""))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = passwdstore.ListKeys([]byte("wrongsecret"))
+	if !errors.Is(err, crypto.ErrWrongPasswd) {
+		failTestCase(t, "wrongsecret", err, crypto.ErrWrongPasswd)
+	}
+
+	// errors are wrapped once by the package, so the message does not repeat its prefix.
+	if err != nil && err.Error() != "passwdstore: crypto: wrong password" {
+		failTestCase(t, "wrongsecret", err.Error(), "passwdstore: crypto: wrong password")
+	}
+
+	data, err := os.ReadFile(passwdStoreFilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type test struct {
+		component int
+		offset    int
+		err       error
+	}
+
+	tests := []test{
+		// Flips a bit in the ciphertext.
+		{component: 2, offset: -1, err: passwdstore.ErrPasswdFileIntegrityFail},
+		// Flips a bit in the gcm nonce.
+		{component: 2, offset: 0, err: passwdstore.ErrPasswdFileIntegrityFail},
+		// Flips a bit in the argon2id salt, changing the derived key.
+		{component: 1, offset: -1, err: crypto.ErrWrongPasswd},
+	}
+
+	for _, test := range tests {
+		t.Log(test)
+		pData := bytes.Split(bytes.Clone(data), []byte{'.'})
+
+		b, err := hex.DecodeString(string(pData[test.component]))
 		if err != nil {
 			t.Fatal(err)
 		}
 
-		if value != "" {
-			failTestCase(t, test, value, test[1])
+		offset := test.offset
+		if offset < 0 {
+			offset += len(b)
+		}
+
+		b[offset] ^= 1
+		pData[test.component] = []byte(hex.EncodeToString(b))
+
+		err = os.WriteFile(passwdStoreFilePath, bytes.Join(pData, []byte{'.'}), 0o600)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = passwdstore.ListKeys(passwd)
+		if !errors.Is(err, test.err) {
+			failTestCase(t, test, err, test.err)
+		}
+	}
+
+	// the argon2id time and memory follow the version, cipher, key check and kdf bytes of the header.
+	for _, offset := range []int{67, 71} {
+		t.Log(offset)
+		pData := bytes.Split(bytes.Clone(data), []byte{'.'})
+
+		b, err := hex.DecodeString(string(pData[1]))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		copy(b[offset:offset+4], []byte{0xff, 0xff, 0xff, 0xff})
+		pData[1] = []byte(hex.EncodeToString(b))
+
+		err = os.WriteFile(passwdStoreFilePath, bytes.Join(pData, []byte{'.'}), 0o600)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = passwdstore.ListKeys(passwd)
+		if !errors.Is(err, passwdstore.ErrPasswdFileManuallyEdited) {
+			failTestCase(t, offset, err, passwdstore.ErrPasswdFileManuallyEdited)
+		}
+	}
+}
+
+func TestVaultPasswdNotStored(t *testing.T) {
+	tempDir := t.TempDir()
+
+	passwdStoreFilePath := filepath.Join(tempDir, ".vault", ".passwdstore")
+
+	passwd := []byte("secret")
+
+	err := passwdstore.Init(passwdStoreFilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = passwdstore.Put("hi", "how are you", passwd)
+	if !errors.Is(err, passwdstore.ErrVaultPasswdNotSet) {
+		failTestCase(t, "put before setting password", err, passwdstore.ErrVaultPasswdNotSet)
+	}
+
+	err = passwdstore.ChangePasswd([]byte(""), []byte(""))
+	if !errors.Is(err, passwdstore.ErrVaultPasswdNotSet) {
+		failTestCase(t, "empty password", err, passwdstore.ErrVaultPasswdNotSet)
+	}
+
+	err = passwdstore.ChangePasswd(passwd, []byte(""))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = passwdstore.Put("hi", "how are you", passwd)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := passwdstore.DecryptFile(passwd)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if bytes.Contains(data, passwd) {
+		failTestCase(t, string(passwd), string(data), "vault password not in the file")
+	}
+}
+
+func TestFailedWrite(t *testing.T) {
+	tempDir := t.TempDir()
+
+	passwdStoreFilePath := filepath.Join(tempDir, ".vault", ".passwdstore")
+
+	passwd := []byte("secret")
+
+	err := passwdstore.Init(passwdStoreFilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = passwdstore.ChangePasswd(passwd, []byte(""))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = passwdstore.Put("hi", "how are you", passwd)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	restore := passwdstore.FailWrites()
+
+	err = passwdstore.Put("hello", "fine", passwd)
+	if !errors.Is(err, passwdstore.ErrWriteFailed) {
+		failTestCase(t, "failed write", err, passwdstore.ErrWriteFailed)
+	}
+
+	restore()
+
+	list, err := passwdstore.ListEntries(passwd)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(list) != 1 || list[0][0] != "hi" || list[0][1] != "how are you" {
+		failTestCase(t, "failed write", list, [][2]string{{"hi", "how are you"}})
+	}
+
+	files, err := os.ReadDir(filepath.Dir(passwdStoreFilePath))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, file := range files {
+		if strings.Contains(file.Name(), ".tmp") {
+			failTestCase(t, "failed write", file.Name(), "temporary file removed")
 		}
 	}
 }
+
+func TestPermissions(t *testing.T) {
+	tempDir := t.TempDir()
+
+	passwdStoreFilePath := filepath.Join(tempDir, ".vault", ".passwdstore")
+
+	passwd := []byte("secret")
+
+	err := passwdstore.Init(passwdStoreFilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = passwdstore.ChangePasswd(passwd, []byte(""))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := [][2]string{
+		{filepath.Dir(passwdStoreFilePath), "-rwx------"},
+		{passwdStoreFilePath, "-rw-------"},
+	}
+
+	for _, test := range tests {
+		t.Log(test)
+		stat, err := os.Stat(test[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if stat.Mode().Perm().String() != test[1] {
+			failTestCase(t, test[0], stat.Mode().Perm().String(), test[1])
+		}
+	}
+
+	for _, test := range tests {
+		t.Log(test)
+		err = os.Chmod(test[0], 0o755)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = passwdstore.ListKeys(passwd)
+		if !errors.Is(err, passwdstore.ErrInsecurePermissions) {
+			failTestCase(t, test[0], err, passwdstore.ErrInsecurePermissions)
+		}
+
+		err = passwdstore.FixPermissions()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = passwdstore.ListKeys(passwd)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestLock(t *testing.T) {
+	tempDir := t.TempDir()
+
+	passwdStoreFilePath := filepath.Join(tempDir, ".vault", ".passwdstore")
+
+	passwd := []byte("secret")
+
+	err := passwdstore.Init(passwdStoreFilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = passwdstore.ChangePasswd(passwd, []byte(""))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	unlock, err := passwdstore.LockVault()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	passwdstore.SetLockTimeout(100 * time.Millisecond)
+	defer passwdstore.SetLockTimeout(passwdstore.DefaultLockTimeout)
+
+	err = passwdstore.Put("hi", "how are you", passwd)
+	if !errors.Is(err, passwdstore.ErrVaultLocked) {
+		failTestCase(t, "locked vault", err, passwdstore.ErrVaultLocked)
+	}
+
+	err = unlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	passwdstore.SetLockTimeout(passwdstore.DefaultLockTimeout)
+
+	tests := []string{"a", "b", "c", "d"}
+
+	var wg sync.WaitGroup
+
+	errs := make(chan error, len(tests))
+
+	for _, test := range tests {
+		wg.Add(1)
+
+		go func(k string) {
+			defer wg.Done()
+
+			errs <- passwdstore.Put(k, k, passwd)
+		}(test)
+	}
+
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	list, err := passwdstore.ListKeys(passwd)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(list) != len(tests) {
+		failTestCase(t, tests, list, tests)
+	}
+}
+
+func TestOpen(t *testing.T) {
+	t.Parallel()
+
+	tempDir := t.TempDir()
+
+	opts := &passwdstore.Options{
+		KDFTime:    1,
+		KDFMemory:  1024,
+		KDFThreads: 1,
+	}
+
+	tests := [][3]string{
+		{"first", "hi", "how are you"},
+		{"second", "hi", "fine"},
+	}
+
+	vaults := make([]*passwdstore.Vault, 0, len(tests))
+
+	for _, test := range tests {
+		t.Log(test)
+		v, err := passwdstore.Open(filepath.Join(tempDir, test[0], ".passwdstore"), opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		passwd := []byte(test[0])
+
+		err = v.ChangePasswd(passwd, []byte(""))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = v.Put(test[1], test[2], passwd)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		vaults = append(vaults, v)
+	}
+
+	for i, test := range tests {
+		t.Log(test)
+		value, err := vaults[i].Get(test[1], []byte(test[0]))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if value != test[2] {
+			failTestCase(t, test, value, test[2])
+		}
+	}
+
+	_, err := passwdstore.Open("relative/.passwdstore", nil)
+	if !errors.Is(err, passwdstore.ErrFilePathNotAbsolute) {
+		failTestCase(t, "relative/.passwdstore", err, passwdstore.ErrFilePathNotAbsolute)
+	}
+
+	_, err = passwdstore.Open(tempDir, nil)
+	if err == nil {
+		failTestCase(t, "directory", err, "error")
+	}
+
+	// opening a vault which is being created by others never truncates what they wrote.
+	f := filepath.Join(tempDir, "concurrent", ".passwdstore")
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			v, err := passwdstore.Open(f, opts)
+			if err != nil {
+				t.Error(err)
+
+				return
+			}
+
+			err = v.ChangePasswd([]byte("secret"), nil)
+			if err != nil && !errors.Is(err, crypto.ErrWrongPasswd) {
+				t.Error(err)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	v, err := passwdstore.Open(f, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = v.ListKeys([]byte("secret"))
+	if err != nil {
+		failTestCase(t, "concurrent open", err, nil)
+	}
+}
+
+func TestSession(t *testing.T) {
+	t.Parallel()
+
+	v, err := passwdstore.Open(filepath.Join(t.TempDir(), ".vault", ".passwdstore"), &passwdstore.Options{
+		KDFTime:    1,
+		KDFMemory:  1024,
+		KDFThreads: 1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	passwd := []byte("secret")
+
+	s, err := v.Unlock([]byte(""))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = s.Put("hi", "how are you")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = s.Commit()
+	if !errors.Is(err, passwdstore.ErrVaultPasswdNotSet) {
+		failTestCase(t, "commit before setting password", err, passwdstore.ErrVaultPasswdNotSet)
+	}
+
+	err = s.ChangePasswd(passwd)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := [][2]string{
+		{"a", "1"},
+		{"b", "2"},
+		{"c", "3"},
+	}
+
+	for _, test := range tests {
+		t.Log(test)
+		err = s.Put(test[0], test[1])
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err = s.Commit()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s.Lock()
+
+	_, err = s.Get("a")
+	if !errors.Is(err, passwdstore.ErrSessionLocked) {
+		failTestCase(t, "get after lock", err, passwdstore.ErrSessionLocked)
+	}
+
+	for _, test := range tests {
+		t.Log(test)
+		value, err := v.Get(test[0], passwd)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if value != test[1] {
+			failTestCase(t, test, value, test[1])
+		}
+	}
+
+	s, err = v.Unlock(passwd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	err = v.Put("d", "4", passwd)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = s.Delete("a")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = s.Commit()
+	if !errors.Is(err, passwdstore.ErrVaultModified) {
+		failTestCase(t, "commit after concurrent put", err, passwdstore.ErrVaultModified)
+	}
+
+	// updates hold the lock from the read to the write, so concurrent ones wait instead of failing.
+	updates := []string{"e", "f", "g", "h"}
+
+	var wg sync.WaitGroup
+
+	errs := make(chan error, len(updates))
+
+	for _, update := range updates {
+		wg.Add(1)
+
+		go func(k string) {
+			defer wg.Done()
+
+			errs <- v.Update(passwd, func(s *passwdstore.Session) error {
+				_, err := s.Get(k)
+				if !errors.Is(err, passwdstore.ErrEntryNotFound) {
+					return err
+				}
+
+				return s.Put(k, k)
+			})
+		}(update)
+	}
+
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			failTestCase(t, updates, err, nil)
+		}
+	}
+
+	err = v.Update(passwd, func(s *passwdstore.Session) error {
+		err := s.Put("i", "9")
+		if err != nil {
+			return err
+		}
+
+		return passwdstore.ErrEntryNotFound
+	})
+	if !errors.Is(err, passwdstore.ErrEntryNotFound) {
+		failTestCase(t, "failed update", err, passwdstore.ErrEntryNotFound)
+	}
+
+	keys, err := v.ListKeys(passwd)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"a", "b", "c", "d", "e", "f", "g", "h", "hi"}
+	if strings.Join(keys, ",") != strings.Join(want, ",") {
+		failTestCase(t, updates, keys, want)
+	}
+}
+
+func TestEntry(t *testing.T) {
+	t.Parallel()
+
+	v, err := passwdstore.Open(filepath.Join(t.TempDir(), ".vault", ".passwdstore"), &passwdstore.Options{
+		KDFTime:    1,
+		KDFMemory:  1024,
+		KDFThreads: 1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	passwd := []byte("secret")
+
+	err = v.ChangePasswd(passwd, []byte(""))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	test := passwdstore.Entry{
+		Password: "how are you",
+		Username: "hi",
+		URL:      "https://example.com",
+		Notes:    "notes",
+		Tags:     []string{"work", "email"},
+		Fields: map[string]string{
+			"pin": "1234",
+		},
+	}
+
+	err = v.PutEntry("hi", test, passwd)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e, err := v.GetEntry("hi", passwd)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if e.Password != test.Password || e.Username != test.Username || e.URL != test.URL ||
+		e.Notes != test.Notes || strings.Join(e.Tags, ",") != "work,email" || e.Fields["pin"] != "1234" {
+		failTestCase(t, test, e, test)
+	}
+
+	if e.Created.IsZero() || !e.Created.Equal(e.Modified) {
+		failTestCase(t, test, e.Created, e.Modified)
+	}
+
+	err = v.Put("hi", "fine", passwd)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	updated, err := v.GetEntry("hi", passwd)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if updated.Password != "fine" || updated.Username != test.Username {
+		failTestCase(t, "fine", updated, "password updated and other fields kept")
+	}
+
+	if !updated.Created.Equal(e.Created) || updated.Modified.Before(e.Modified) {
+		failTestCase(t, "fine", updated.Created, e.Created)
+	}
+}
+
+func TestBinaryEntry(t *testing.T) {
+	t.Parallel()
+
+	v, err := passwdstore.Open(filepath.Join(t.TempDir(), ".vault", ".passwdstore"), &passwdstore.Options{
+		KDFTime:    1,
+		KDFMemory:  1024,
+		KDFThreads: 1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	passwd := []byte("secret")
+
+	err = v.ChangePasswd(passwd, []byte(""))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	test := []byte{0, 1, 2, 0xff, '\n', 'k', 'e', 'y', '\n'}
+
+	err = v.PutEntry("cert", passwdstore.Entry{Data: test, Username: "hi"}, passwd)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e, err := v.GetEntry("cert", passwd)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(e.Value(), test) || e.Password != "" {
+		failTestCase(t, test, e.Value(), test)
+	}
+
+	list, err := v.ListEntries(passwd)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(list) != 1 || list[0][1] != "9 bytes" {
+		failTestCase(t, test, list, "binary value listed as its size")
+	}
+
+	err = v.Put("cert", "fine", passwd)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e, err = v.GetEntry("cert", passwd)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(e.Value()) != "fine" || e.Data != nil || e.Username != "hi" {
+		failTestCase(t, "fine", e, "binary value replaced by the password")
+	}
+}
+
+func TestSuggest(t *testing.T) {
+	t.Parallel()
+
+	keys := []string{"github", "gitlab", "gmail", "work/github", "bank"}
+
+	tests := [][2]string{
+		{"githb", "github,gitlab"},
+		{"github", "github,gitlab,work/github"},
+		{"GitLab", "gitlab,github"},
+		{"gmial", "gmail"},
+		{"bnak", "bank"},
+		{"nothingclose", ""},
+	}
+
+	for _, test := range tests {
+		t.Log(test)
+		out := strings.Join(passwdstore.Suggest(test[0], keys), ",")
+
+		if out != test[1] {
+			failTestCase(t, test[0], out, test[1])
+		}
+	}
+}
+
+func TestTree(t *testing.T) {
+	t.Parallel()
+
+	v, err := passwdstore.Open(filepath.Join(t.TempDir(), ".vault", ".passwdstore"), &passwdstore.Options{
+		KDFTime:    1,
+		KDFMemory:  1024,
+		KDFThreads: 1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	passwd := []byte("secret")
+
+	err = v.ChangePasswd(passwd, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := v.Unlock(passwd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	for _, k := range []string{"workshop", "work/gh", "work/aws/prod", "work/aws/dev", "mail", "work"} {
+		err = s.Put(k, k)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := [][2]string{
+		{"", "mail,work,work/aws/dev,work/aws/prod,work/gh,workshop"},
+		{"/", "mail,work,work/aws/dev,work/aws/prod,work/gh,workshop"},
+		{"work", "work,work/aws/dev,work/aws/prod,work/gh"},
+		{"work/", "work,work/aws/dev,work/aws/prod,work/gh"},
+		{"work/aws", "work/aws/dev,work/aws/prod"},
+		{"work/a", ""},
+	}
+
+	for _, test := range tests {
+		t.Log(test)
+
+		keys, err := s.ListKeysWithPrefix(test[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		out := strings.Join(keys, ",")
+		if out != test[1] {
+			failTestCase(t, test[0], out, test[1])
+		}
+	}
+
+	_, err = s.MoveTree("work/aws/dev", "work/gh", false)
+	if !errors.Is(err, passwdstore.ErrEntryExists) {
+		failTestCase(t, "move over an entry", err, passwdstore.ErrEntryExists)
+	}
+
+	_, err = s.MoveTree("nothing", "other", false)
+	if !errors.Is(err, passwdstore.ErrEntryNotFound) {
+		failTestCase(t, "move missing folder", err, passwdstore.ErrEntryNotFound)
+	}
+
+	n, err := s.MoveTree("work/aws", "old", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	value, err := s.Get("old/prod")
+	if n != 2 || err != nil || value != "work/aws/prod" {
+		failTestCase(t, "move work/aws to old", []any{n, value, err}, []any{2, "work/aws/prod", nil})
+	}
+
+	_, err = s.DeleteTree("")
+	if !errors.Is(err, passwdstore.ErrInvalidKey) {
+		failTestCase(t, "delete root", err, passwdstore.ErrInvalidKey)
+	}
+
+	n, err = s.DeleteTree("work")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keys, err := s.ListKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := strings.Join(keys, ",")
+	if n != 2 || out != "mail,old/dev,old/prod,workshop" {
+		failTestCase(t, "delete work", []any{n, out}, []any{2, "mail,old/dev,old/prod,workshop"})
+	}
+}
+
+func TestSearch(t *testing.T) {
+	t.Parallel()
+
+	v, err := passwdstore.Open(filepath.Join(t.TempDir(), ".vault", ".passwdstore"), &passwdstore.Options{
+		KDFTime:    1,
+		KDFMemory:  1024,
+		KDFThreads: 1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	passwd := []byte("secret")
+
+	err = v.ChangePasswd(passwd, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := v.Unlock(passwd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	entries := map[string]passwdstore.Entry{
+		"work/db":        {Password: "postgres", Username: "admin", URL: "https://db.example.com", Tags: []string{"Database", "prod"}},
+		"personal/mysql": {Password: "hunter2", Username: "bob", Tags: []string{"database"}},
+		"mail":           {Password: "admin", Notes: "Old PostgreSQL notes"},
+	}
+
+	for k, e := range entries {
+		err = s.PutEntry(k, e)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	type test struct {
+		query passwdstore.Query
+		// want are the names of the results with the fields that matched.
+		want string
+	}
+
+	tests := []test{
+		{passwdstore.Query{Pattern: "DB"}, "work/db:name,url"},
+		{passwdstore.Query{Pattern: "admin"}, "work/db:username"},
+		{passwdstore.Query{Pattern: "hunter2"}, ""},
+		{passwdstore.Query{Tags: []string{"database"}}, "personal/mysql:,work/db:"},
+		{passwdstore.Query{Tags: []string{"database", "prod"}}, "work/db:"},
+		{passwdstore.Query{Pattern: "postgres", Fields: []string{passwdstore.FieldNotes}}, "mail:notes"},
+		{passwdstore.Query{Pattern: "data*", Mode: passwdstore.MatchGlob}, "personal/mysql:tags,work/db:tags"},
+		{passwdstore.Query{Pattern: "*/m?sql", Mode: passwdstore.MatchGlob}, "personal/mysql:name"},
+		{passwdstore.Query{Pattern: "^[a-z]+$", Mode: passwdstore.MatchRegexp}, "mail:name,personal/mysql:username,tags,work/db:username,tags"},
+		{passwdstore.Query{Pattern: "a", Folder: "work"}, "work/db:username,url,tags"},
+	}
+
+	for _, test := range tests {
+		t.Log(test)
+
+		results, err := s.Search(test.query)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		temp := make([]string, 0, len(results))
+		for _, r := range results {
+			temp = append(temp, r.Name+":"+strings.Join(r.Fields, ","))
+		}
+
+		out := strings.Join(temp, ",")
+		if out != test.want {
+			failTestCase(t, test.query, out, test.want)
+		}
+	}
+
+	_, err = s.Search(passwdstore.Query{Pattern: "(", Mode: passwdstore.MatchRegexp})
+	if !errors.Is(err, passwdstore.ErrInvalidPattern) {
+		failTestCase(t, "(", err, passwdstore.ErrInvalidPattern)
+	}
+
+	_, err = s.Search(passwdstore.Query{Pattern: "x", Fields: []string{"password"}})
+	if !errors.Is(err, passwdstore.ErrUnknownField) {
+		failTestCase(t, "password field", err, passwdstore.ErrUnknownField)
+	}
+}
+
+func TestRenameCopy(t *testing.T) {
+	t.Parallel()
+
+	v, err := passwdstore.Open(filepath.Join(t.TempDir(), ".vault", ".passwdstore"), &passwdstore.Options{
+		KDFTime:    1,
+		KDFMemory:  1024,
+		KDFThreads: 1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	passwd := []byte("secret")
+
+	err = v.ChangePasswd(passwd, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, k := range []string{"a", "b", "dir/c"} {
+		err = v.PutEntry(k, passwdstore.Entry{Password: k, Tags: []string{k}}, passwd)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err = v.Rename("a", "b", false, passwd)
+	if !errors.Is(err, passwdstore.ErrEntryExists) {
+		failTestCase(t, "rename a to b", err, passwdstore.ErrEntryExists)
+	}
+
+	err = v.Copy("x", "y", false, passwd)
+	if !errors.Is(err, passwdstore.ErrEntryNotFound) {
+		failTestCase(t, "copy x to y", err, passwdstore.ErrEntryNotFound)
+	}
+
+	old, err := v.GetEntry("a", passwd)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = v.Rename("a", "b", true, passwd)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = v.Copy("b", "d", false, passwd)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = v.CopyTree("dir", "backup", false, passwd)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keys, err := v.ListKeys(passwd)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := strings.Join(keys, ",")
+	if out != "b,backup/c,d,dir/c" {
+		failTestCase(t, "keys", out, "b,backup/c,d,dir/c")
+	}
+
+	renamed, err := v.GetEntry("b", passwd)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if renamed.Password != "a" || !renamed.Created.Equal(old.Created) || !renamed.Modified.Equal(old.Modified) {
+		failTestCase(t, "renamed entry", renamed, old)
+	}
+
+	copied, err := v.GetEntry("d", passwd)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if copied.Password != "a" || strings.Join(copied.Tags, ",") != "a" || copied.Created.Before(old.Created) {
+		failTestCase(t, "copied entry", copied, old)
+	}
+}
+
+func TestHistory(t *testing.T) {
+	t.Parallel()
+
+	v, err := passwdstore.Open(filepath.Join(t.TempDir(), ".vault", ".passwdstore"), &passwdstore.Options{
+		KDFTime:      1,
+		KDFMemory:    1024,
+		KDFThreads:   1,
+		HistoryLimit: 2,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	passwd := []byte("secret")
+
+	err = v.ChangePasswd(passwd, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := v.Unlock(passwd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	// history returns the passwords in the history of the entry "a".
+	history := func() string {
+		e, err := s.GetEntry("a")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		temp := make([]string, 0, len(e.History))
+		for _, i := range e.History {
+			temp = append(temp, i.Password)
+		}
+
+		return strings.Join(temp, ",")
+	}
+
+	tests := [][2]string{
+		{"1", ""},
+		{"2", "1"},
+		{"2", "1"},
+		{"3", "2,1"},
+		{"4", "3,2"},
+	}
+
+	for _, test := range tests {
+		t.Log(test)
+
+		err = s.Put("a", test[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		out := history()
+		if out != test[1] {
+			failTestCase(t, test[0], out, test[1])
+		}
+	}
+
+	e, err := s.GetEntry("a")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e.Notes, e.History = "notes", nil
+
+	err = s.PutEntry("a", e)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := history()
+	if out != "3,2" {
+		failTestCase(t, "change notes", out, "3,2")
+	}
+
+	err = s.Restore("a", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	value, err := s.Get("a")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out = history()
+	if value != "2" || out != "4,3" {
+		failTestCase(t, "restore 2", []string{value, out}, []string{"2", "4,3"})
+	}
+
+	err = s.Restore("a", 3)
+	if !errors.Is(err, passwdstore.ErrVersionNotFound) {
+		failTestCase(t, "restore 3", err, passwdstore.ErrVersionNotFound)
+	}
+}
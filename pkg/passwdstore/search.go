@@ -0,0 +1,254 @@
+package passwdstore
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Fields of the entries which are searched. Passwords, binary values, custom fields and otp keys are never searched.
+const (
+	FieldName     = "name"
+	FieldUsername = "username"
+	FieldURL      = "url"
+	FieldTags     = "tags"
+	FieldNotes    = "notes"
+)
+
+// SearchFields are all the fields which are searched in the order they are reported.
+var SearchFields = []string{FieldName, FieldUsername, FieldURL, FieldTags, FieldNotes}
+
+// MatchMode is how the pattern of a query is matched against the fields.
+type MatchMode int
+
+const (
+	// MatchSubstring matches fields which contain the pattern ignoring the case.
+	MatchSubstring MatchMode = iota
+	// MatchGlob matches whole fields with a pattern of "*", "?" and "[...]" ignoring the case.
+	// Unlike path.Match a "*" also matches the folder separator.
+	MatchGlob
+	// MatchRegexp matches fields which contain a match of the regular expression.
+	MatchRegexp
+)
+
+var (
+	// ErrInvalidPattern is the error thrown when the pattern of a query is not a valid glob or regular expression.
+	ErrInvalidPattern = errors.New("passwdstore: invalid search pattern")
+	// ErrUnknownField is the error thrown when a query searches a field which is not one of SearchFields.
+	ErrUnknownField = errors.New("passwdstore: unknown search field")
+)
+
+// Query is a search over the entries of the store.
+type Query struct {
+	// Pattern is matched against the fields. The empty pattern matches every entry.
+	Pattern string
+	Mode    MatchMode
+	// Fields are the fields searched, all of SearchFields if empty.
+	Fields []string
+	// Tags are the tags the entries must all have, compared ignoring the case.
+	Tags []string
+	// Folder is the folder the entries must be in, the root if empty.
+	Folder string
+}
+
+// Result is an entry which matches a query.
+type Result struct {
+	Name  string
+	Entry Entry
+	// Fields are the fields which matched the pattern.
+	Fields []string
+}
+
+// globRegexp converts the glob "g" to an anchored regular expression.
+func globRegexp(g string) string {
+	var b strings.Builder
+
+	b.WriteString("^(?is)")
+
+	for i := 0; i < len(g); i++ {
+		switch g[i] {
+		case '*':
+			b.WriteString(".*")
+		case '?':
+			b.WriteString(".")
+		case '[':
+			j := strings.IndexByte(g[i+1:], ']')
+			if j < 0 {
+				b.WriteString(`\[`)
+
+				continue
+			}
+
+			class := g[i+1 : i+1+j]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+
+			b.WriteString("[" + class + "]")
+			i += j + 1
+		case '\\':
+			if i+1 < len(g) {
+				i++
+			}
+
+			b.WriteString(regexp.QuoteMeta(g[i : i+1]))
+		default:
+			b.WriteString(regexp.QuoteMeta(g[i : i+1]))
+		}
+	}
+
+	b.WriteString("$")
+
+	return b.String()
+}
+
+// matcher returns the function which matches the pattern of the query against a field.
+func (q Query) matcher() (func(string) bool, error) {
+	switch q.Mode {
+	case MatchSubstring:
+		pattern := strings.ToLower(q.Pattern)
+
+		return func(f string) bool {
+			return strings.Contains(strings.ToLower(f), pattern)
+		}, nil
+	case MatchGlob, MatchRegexp:
+		expr := q.Pattern
+		if q.Mode == MatchGlob {
+			expr = globRegexp(q.Pattern)
+		}
+
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidPattern, err.Error())
+		}
+
+		return re.MatchString, nil
+	default:
+		return nil, fmt.Errorf("%w: unknown match mode %d", ErrInvalidPattern, q.Mode)
+	}
+}
+
+// hasTags reports if the entry has all the tags.
+func hasTags(e Entry, tags []string) bool {
+	for _, t := range tags {
+		found := false
+
+		for _, i := range e.Tags {
+			if strings.EqualFold(i, t) {
+				found = true
+
+				break
+			}
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
+
+// matchFields returns the fields of the entry "k" which match.
+func matchFields(k string, e Entry, fields []string, match func(string) bool) []string {
+	var matched []string
+
+	for _, f := range fields {
+		var values []string
+
+		switch f {
+		case FieldName:
+			values = []string{k}
+		case FieldUsername:
+			values = []string{e.Username}
+		case FieldURL:
+			values = []string{e.URL}
+		case FieldTags:
+			values = e.Tags
+		case FieldNotes:
+			values = []string{e.Notes}
+		}
+
+		for _, v := range values {
+			if v != "" && match(v) {
+				matched = append(matched, f)
+
+				break
+			}
+		}
+	}
+
+	return matched
+}
+
+// Search returns the entries which match the query sorted by name.
+// It fails with ErrInvalidPattern or ErrUnknownField if the query is not valid.
+func (s *Session) Search(q Query) ([]Result, error) {
+	if s.locked {
+		return nil, ErrSessionLocked
+	}
+
+	fields := q.Fields
+	if len(fields) == 0 {
+		fields = SearchFields
+	}
+
+	for _, f := range fields {
+		found := false
+
+		for _, i := range SearchFields {
+			if f == i {
+				found = true
+			}
+		}
+
+		if !found {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownField, f)
+		}
+	}
+
+	match, err := q.matcher()
+	if err != nil {
+		return nil, err
+	}
+
+	var results []Result
+
+	for _, k := range s.treeKeys(q.Folder) {
+		e := s.store.Store[k]
+		if !hasTags(e, q.Tags) {
+			continue
+		}
+
+		var matched []string
+
+		if q.Pattern != "" {
+			matched = matchFields(k, e, fields, match)
+			if len(matched) == 0 {
+				continue
+			}
+		}
+
+		results = append(results, Result{Name: k, Entry: e.clone(), Fields: matched})
+	}
+
+	return results, nil
+}
+
+// Search returns the entries which match the query sorted by name.
+func (v *Vault) Search(q Query, p []byte) ([]Result, error) {
+	var results []Result
+
+	err := v.view(p, func(s *Session) error {
+		var err error
+		results, err = s.Search(q)
+
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
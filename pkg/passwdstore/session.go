@@ -0,0 +1,341 @@
+package passwdstore
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	"github.com/231tr0n/vault/pkg/crypto"
+)
+
+// Session is an unlocked vault returned by Vault.Unlock.
+// It holds the derived key and the decrypted store in memory so that many reads and writes
+// can be done without deriving the key again. Writes are only made to the file on Session.Commit.
+// A Session is not safe for concurrent use.
+type Session struct {
+	v      *Vault
+	key    []byte
+	header fileHeader
+	store  passwdStore
+	// data is the content of the file when it was last read or written by the session.
+	data   []byte
+	dirty  bool
+	locked bool
+}
+
+// setPasswd derives a new key from the password "p" with new kdf params.
+func (s *Session) setPasswd(p []byte) error {
+	if len(p) == 0 {
+		return ErrVaultPasswdNotSet
+	}
+
+	params, err := s.v.newKDFParams()
+	if err != nil {
+		return err
+	}
+
+	key, err := crypto.DeriveKey(p, params)
+	if err != nil {
+		return wrap(err)
+	}
+
+	zero(s.key)
+	s.header, s.key = newFileHeader(params), key
+	s.store.Initialised = true
+	s.dirty = true
+
+	return nil
+}
+
+// commit encrypts the store with the session key and writes it to the file.
+// It must be called with the lock held.
+func (s *Session) commit() error {
+	if !s.dirty {
+		return nil
+	}
+
+	if !s.store.Initialised || len(s.key) == 0 {
+		return ErrVaultPasswdNotSet
+	}
+
+	d, err := s.store.marshal()
+	if err != nil {
+		return err
+	}
+
+	f, err := newFileData(s.header, d, s.key)
+	if err != nil {
+		return err
+	}
+
+	data := f.Bytes()
+
+	err = writeFile(s.v.path, data)
+	if err != nil {
+		return err
+	}
+
+	s.data, s.dirty = data, false
+
+	return nil
+}
+
+// Commit writes all the changes made in the session to the file with a single write.
+// It fails with ErrVaultModified if the file was changed by someone else after the session read it.
+func (s *Session) Commit() error {
+	if s.locked {
+		return ErrSessionLocked
+	}
+
+	if !s.dirty {
+		return nil
+	}
+
+	return s.v.withLock(func() error {
+		data, err := s.v.readFile()
+		if err != nil {
+			return err
+		}
+
+		if !bytes.Equal(data, s.data) {
+			return ErrVaultModified
+		}
+
+		return s.commit()
+	})
+}
+
+// Lock discards the store and zeroes the key held by the session. Uncommitted changes are lost.
+// The session can not be used after it is locked.
+func (s *Session) Lock() {
+	zero(s.key)
+	s.key = nil
+	s.store = newpasswdStore()
+	s.data = nil
+	s.dirty = false
+	s.locked = true
+}
+
+// Close locks the session. It is the same as Session.Lock and can be deferred.
+func (s *Session) Close() {
+	s.Lock()
+}
+
+// Get gets the password of the entry from the store.
+// It fails with ErrEntryNotFound if there is no entry with the key "k".
+func (s *Session) Get(k string) (string, error) {
+	if s.locked {
+		return "", ErrSessionLocked
+	}
+
+	e, ok := s.store.Store[k]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrEntryNotFound, k)
+	}
+
+	return e.Password, nil
+}
+
+// GetEntry gets the entry from the store.
+// It fails with ErrEntryNotFound if there is no entry with the key "k".
+func (s *Session) GetEntry(k string) (Entry, error) {
+	if s.locked {
+		return Entry{}, ErrSessionLocked
+	}
+
+	e, ok := s.store.Store[k]
+	if !ok {
+		return Entry{}, fmt.Errorf("%w: %s", ErrEntryNotFound, k)
+	}
+
+	return e.clone(), nil
+}
+
+// Put puts the password of the entry in the store, keeping the rest of the entry as it is.
+// A binary value of the entry is replaced by the password.
+func (s *Session) Put(k, v string) error {
+	if s.locked {
+		return ErrSessionLocked
+	}
+
+	e := s.store.Store[k]
+	e.Password = v
+	e.Data = nil
+
+	return s.PutEntry(k, e)
+}
+
+// PutEntry puts the entry in the store. The created and modified times and the history of the entry are set by the store.
+// A changed password or binary value is added to the history, which keeps the number of values set with Options.HistoryLimit.
+func (s *Session) PutEntry(k string, e Entry) error {
+	if s.locked {
+		return ErrSessionLocked
+	}
+
+	now := time.Now().UTC()
+
+	e = e.clone()
+	e.Created, e.Modified = now, now
+	e.History = nil
+
+	if old, ok := s.store.Store[k]; ok {
+		e.Created = old.Created
+		e.History = old.History
+
+		if old.Password != e.Password || !bytes.Equal(old.Data, e.Data) {
+			e.History = append([]Version{{Password: old.Password, Data: old.Data, Replaced: now}}, e.History...)
+		}
+	}
+
+	if len(e.History) > s.v.historyLimit {
+		e.History = e.History[:maxInt(s.v.historyLimit, 0)]
+	}
+
+	if len(e.History) == 0 {
+		e.History = nil
+	}
+
+	s.store.Store[k] = e
+	s.dirty = true
+
+	return nil
+}
+
+// ListKeys lists all the keys in the store sorted.
+func (s *Session) ListKeys() ([]string, error) {
+	return s.ListKeysWithPrefix("")
+}
+
+// ListEntries lists all the keys with their passwords in the store sorted by key.
+func (s *Session) ListEntries() ([][2]string, error) {
+	return s.ListEntriesWithPrefix("")
+}
+
+// Delete deletes the key value pair in the store.
+// It fails with ErrEntryNotFound if there is no entry with the key "k".
+func (s *Session) Delete(k string) error {
+	if s.locked {
+		return ErrSessionLocked
+	}
+
+	if _, ok := s.store.Store[k]; !ok {
+		return fmt.Errorf("%w: %s", ErrEntryNotFound, k)
+	}
+
+	delete(s.store.Store, k)
+	s.dirty = true
+
+	return nil
+}
+
+// Restore restores the value of the version "n" in the history of the entry, where 1 is the most recent previous value.
+// The current value is added to the history, so a restore can be undone.
+// It fails with ErrEntryNotFound if there is no entry with the key "k" and with ErrVersionNotFound if its history has no version "n".
+func (s *Session) Restore(k string, n int) error {
+	e, err := s.GetEntry(k)
+	if err != nil {
+		return err
+	}
+
+	if n < 1 || n > len(e.History) {
+		return fmt.Errorf("%w: %s has %d versions", ErrVersionNotFound, k, len(e.History))
+	}
+
+	e.Password, e.Data = e.History[n-1].Password, e.History[n-1].Data
+
+	return s.PutEntry(k, e)
+}
+
+// Rename renames the entry "from" to "to" keeping its created and modified times.
+// It fails with ErrEntryNotFound if there is no entry "from", and with ErrEntryExists if there is an entry "to"
+// unless "overwrite" is set.
+func (s *Session) Rename(from, to string, overwrite bool) error {
+	e, err := s.transferable(from, to, overwrite)
+	if err != nil || from == to {
+		return err
+	}
+
+	delete(s.store.Store, from)
+	s.store.Store[to] = e
+	s.dirty = true
+
+	return nil
+}
+
+// Copy copies the entry "from" to a new entry "to".
+// It fails with ErrEntryNotFound if there is no entry "from", and with ErrEntryExists if there is an entry "to"
+// unless "overwrite" is set.
+func (s *Session) Copy(from, to string, overwrite bool) error {
+	e, err := s.transferable(from, to, overwrite)
+	if err != nil || from == to {
+		return err
+	}
+
+	now := time.Now().UTC()
+
+	e = e.clone()
+	e.Created, e.Modified = now, now
+	s.store.Store[to] = e
+	s.dirty = true
+
+	return nil
+}
+
+// transferable returns the entry "from" if it can be renamed or copied to "to".
+func (s *Session) transferable(from, to string, overwrite bool) (Entry, error) {
+	if s.locked {
+		return Entry{}, ErrSessionLocked
+	}
+
+	e, ok := s.store.Store[from]
+	if !ok {
+		return Entry{}, fmt.Errorf("%w: %s", ErrEntryNotFound, from)
+	}
+
+	if to == "" {
+		return Entry{}, fmt.Errorf("%w: %q", ErrInvalidKey, to)
+	}
+
+	if _, ok := s.store.Store[to]; ok && !overwrite && from != to {
+		return Entry{}, fmt.Errorf("%w: %s", ErrEntryExists, to)
+	}
+
+	return e, nil
+}
+
+// Clear clears all the key value pairs in the store.
+func (s *Session) Clear() error {
+	if s.locked {
+		return ErrSessionLocked
+	}
+
+	s.store.Store = make(map[string]Entry)
+	s.dirty = true
+
+	return nil
+}
+
+// ChangePasswd changes the password for the store. It also sets the password of a vault whose password is not set.
+func (s *Session) ChangePasswd(np []byte) error {
+	if s.locked {
+		return ErrSessionLocked
+	}
+
+	return s.setPasswd(np)
+}
+
+// maxInt returns the larger of "a" and "b".
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+
+	return b
+}
+
+// zero overwrites the key material in "b" with zeroes.
+func zero(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
@@ -0,0 +1,96 @@
+package passwdstore
+
+import (
+	"sort"
+	"strings"
+)
+
+const (
+	// maxSuggestions is the maximum number of suggestions returned by Suggest.
+	maxSuggestions = 3
+	// minSuggestDistance is the edit distance always allowed for a suggestion, even for short keys.
+	minSuggestDistance = 2
+)
+
+// Suggest returns up to three keys from "keys" which are close to "k", closest first.
+// It is used to build "did you mean" suggestions when a key is not found.
+func Suggest(k string, keys []string) []string {
+	type suggestion struct {
+		key      string
+		distance int
+	}
+
+	lk := strings.ToLower(k)
+
+	maxDistance := len(lk) / 3
+	if maxDistance < minSuggestDistance {
+		maxDistance = minSuggestDistance
+	}
+
+	temp := make([]suggestion, 0)
+
+	for _, key := range keys {
+		lkey := strings.ToLower(key)
+
+		d := distance(lk, lkey)
+		if d > maxDistance && (lk == "" || !strings.Contains(lkey, lk)) {
+			continue
+		}
+
+		temp = append(temp, suggestion{key: key, distance: d})
+	}
+
+	sort.Slice(temp, func(i, j int) bool {
+		if temp[i].distance != temp[j].distance {
+			return temp[i].distance < temp[j].distance
+		}
+
+		return temp[i].key < temp[j].key
+	})
+
+	out := make([]string, 0, maxSuggestions)
+	for i := 0; i < len(temp) && i < maxSuggestions; i++ {
+		out = append(out, temp[i].key)
+	}
+
+	return out
+}
+
+// distance returns the levenshtein edit distance between "a" and "b".
+func distance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+
+			cur[j] = minInt(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+
+		prev, cur = cur, prev
+	}
+
+	return prev[len(rb)]
+}
+
+func minInt(a int, b ...int) int {
+	for _, i := range b {
+		if i < a {
+			a = i
+		}
+	}
+
+	return a
+}
@@ -0,0 +1,261 @@
+package passwdstore
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Separator separates the folders of path like keys such as "work/aws/prod".
+const Separator = "/"
+
+// cleanPath removes the separators around the path "p", so that "work" and "work/" are the same folder.
+func cleanPath(p string) string {
+	return strings.Trim(p, Separator)
+}
+
+// InTree reports whether the key "k" is the path "p" or is in the folder "p".
+// The empty path is the root which has all the keys. "work" has "work/aws" but not "workshop".
+func InTree(k, p string) bool {
+	p = cleanPath(p)
+
+	return p == "" || k == p || strings.HasPrefix(k, p+Separator)
+}
+
+// treeKeys returns the keys in the tree "p" sorted.
+func (s *Session) treeKeys(p string) []string {
+	temp := make([]string, 0, len(s.store.Store))
+	for i := range s.store.Store {
+		if InTree(i, p) {
+			temp = append(temp, i)
+		}
+	}
+
+	sort.Strings(temp)
+
+	return temp
+}
+
+// ListKeysWithPrefix lists the keys in the tree "p" sorted.
+func (s *Session) ListKeysWithPrefix(p string) ([]string, error) {
+	if s.locked {
+		return nil, ErrSessionLocked
+	}
+
+	return s.treeKeys(p), nil
+}
+
+// ListEntriesWithPrefix lists the keys in the tree "p" with their passwords sorted by key.
+// Binary values are listed as their size, like "9 bytes", instead of an empty password.
+func (s *Session) ListEntriesWithPrefix(p string) ([][2]string, error) {
+	if s.locked {
+		return nil, ErrSessionLocked
+	}
+
+	keys := s.treeKeys(p)
+
+	temp := make([][2]string, 0, len(keys))
+	for _, i := range keys {
+		e := s.store.Store[i]
+		if e.Data != nil {
+			temp = append(temp, [2]string{i, fmt.Sprintf("%d bytes", len(e.Data))})
+
+			continue
+		}
+
+		temp = append(temp, [2]string{i, e.Password})
+	}
+
+	return temp, nil
+}
+
+// DeleteTree deletes the entry "p" and all the entries in the folder "p" and returns how many were deleted.
+// It fails with ErrEntryNotFound if there are none, and with ErrInvalidKey for the root.
+func (s *Session) DeleteTree(p string) (int, error) {
+	if s.locked {
+		return 0, ErrSessionLocked
+	}
+
+	if cleanPath(p) == "" {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidKey, p)
+	}
+
+	keys := s.treeKeys(p)
+	if len(keys) == 0 {
+		return 0, fmt.Errorf("%w: %s", ErrEntryNotFound, p)
+	}
+
+	for _, i := range keys {
+		delete(s.store.Store, i)
+	}
+
+	s.dirty = true
+
+	return len(keys), nil
+}
+
+// treeMoves returns the keys of the entry "from" and the entries in the folder "from" mapped to their keys in "to",
+// so "work/aws/prod" in "work/aws" moved to "old" becomes "old/prod".
+// It fails with ErrEntryNotFound if there are none, and with ErrEntryExists if an entry would be overwritten
+// unless "overwrite" is set. Entries which are moved themselves are not counted as overwritten when "move" is set.
+func (s *Session) treeMoves(from, to string, overwrite, move bool) (map[string]string, error) {
+	from, to = cleanPath(from), cleanPath(to)
+	if from == "" || to == "" {
+		return nil, fmt.Errorf("%w: can't move or copy the root", ErrInvalidKey)
+	}
+
+	keys := s.treeKeys(from)
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrEntryNotFound, from)
+	}
+
+	moves := make(map[string]string, len(keys))
+
+	for _, i := range keys {
+		k := to + i[len(from):]
+		if _, ok := s.store.Store[k]; ok && !overwrite && !(move && InTree(k, from)) {
+			return nil, fmt.Errorf("%w: %s", ErrEntryExists, k)
+		}
+
+		moves[i] = k
+	}
+
+	return moves, nil
+}
+
+// MoveTree moves the entry "from" and all the entries in the folder "from" to "to" and returns how many were moved,
+// so "work/aws/prod" in "work/aws" moved to "old" becomes "old/prod". The entries keep their created and modified times.
+// It fails with ErrEntryNotFound if there are none, and with ErrEntryExists if an entry would be overwritten
+// unless "overwrite" is set.
+func (s *Session) MoveTree(from, to string, overwrite bool) (int, error) {
+	if s.locked {
+		return 0, ErrSessionLocked
+	}
+
+	moves, err := s.treeMoves(from, to, overwrite, true)
+	if err != nil {
+		return 0, err
+	}
+
+	moved := make(map[string]Entry, len(moves))
+	for i, k := range moves {
+		moved[k] = s.store.Store[i]
+		delete(s.store.Store, i)
+	}
+
+	for k, e := range moved {
+		s.store.Store[k] = e
+	}
+
+	s.dirty = true
+
+	return len(moves), nil
+}
+
+// CopyTree copies the entry "from" and all the entries in the folder "from" to "to" like MoveTree
+// and returns how many were copied. The copies are new entries with new created and modified times.
+func (s *Session) CopyTree(from, to string, overwrite bool) (int, error) {
+	if s.locked {
+		return 0, ErrSessionLocked
+	}
+
+	moves, err := s.treeMoves(from, to, overwrite, false)
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now().UTC()
+
+	copied := make(map[string]Entry, len(moves))
+	for i, k := range moves {
+		e := s.store.Store[i].clone()
+		e.Created, e.Modified = now, now
+		copied[k] = e
+	}
+
+	for k, e := range copied {
+		s.store.Store[k] = e
+	}
+
+	s.dirty = true
+
+	return len(moves), nil
+}
+
+// ListKeysWithPrefix lists the keys in the tree "p" sorted.
+func (v *Vault) ListKeysWithPrefix(p string, passwd []byte) ([]string, error) {
+	var keys []string
+
+	err := v.view(passwd, func(s *Session) error {
+		var err error
+		keys, err = s.ListKeysWithPrefix(p)
+
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+// ListEntriesWithPrefix lists the keys in the tree "p" with their passwords sorted by key.
+// Binary values are listed as their size.
+func (v *Vault) ListEntriesWithPrefix(p string, passwd []byte) ([][2]string, error) {
+	var entries [][2]string
+
+	err := v.view(passwd, func(s *Session) error {
+		var err error
+		entries, err = s.ListEntriesWithPrefix(p)
+
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// DeleteTree deletes the entry "p" and all the entries in the folder "p" and returns how many were deleted.
+func (v *Vault) DeleteTree(p string, passwd []byte) (int, error) {
+	var n int
+
+	err := v.update(passwd, func(s *Session) error {
+		var err error
+		n, err = s.DeleteTree(p)
+
+		return err
+	})
+
+	return n, err
+}
+
+// MoveTree moves the entry "from" and all the entries in the folder "from" to "to" and returns how many were moved.
+func (v *Vault) MoveTree(from, to string, overwrite bool, passwd []byte) (int, error) {
+	var n int
+
+	err := v.update(passwd, func(s *Session) error {
+		var err error
+		n, err = s.MoveTree(from, to, overwrite)
+
+		return err
+	})
+
+	return n, err
+}
+
+// CopyTree copies the entry "from" and all the entries in the folder "from" to "to" and returns how many were copied.
+func (v *Vault) CopyTree(from, to string, overwrite bool, passwd []byte) (int, error) {
+	var n int
+
+	err := v.update(passwd, func(s *Session) error {
+		var err error
+		n, err = s.CopyTree(from, to, overwrite)
+
+		return err
+	})
+
+	return n, err
+}
@@ -0,0 +1,450 @@
+package passwdstore
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/231tr0n/vault/pkg/crypto"
+)
+
+// DefaultHistoryLimit is the default number of previous values kept in the history of each entry.
+const DefaultHistoryLimit = 10
+
+// Options configures a Vault opened with Open.
+// The zero value of each field selects its default.
+type Options struct {
+	// LockTimeout is the time to wait for another process to release the vault
+	// before failing with ErrVaultLocked. Defaults to DefaultLockTimeout.
+	LockTimeout time.Duration
+	// KDFTime, KDFMemory and KDFThreads are the argon2id cost parameters used when the vault is written.
+	// They default to crypto.DefaultArgon2idTime, crypto.DefaultArgon2idMemory and crypto.DefaultArgon2idThreads.
+	// Files are always read with the parameters stored in them.
+	KDFTime    uint32
+	KDFMemory  uint32
+	KDFThreads uint8
+	// HistoryLimit is the number of previous values kept in the history of each entry.
+	// Defaults to DefaultHistoryLimit, and a negative limit keeps no history.
+	HistoryLimit int
+}
+
+// Vault is a handle to a password store file.
+// Multiple vaults can be open at the same time and a Vault is safe for concurrent use.
+type Vault struct {
+	path        string
+	lockTimeout time.Duration
+	kdfTime     uint32
+	kdfMemory   uint32
+	kdfThreads  uint8
+	// historyLimit is the number of previous values kept per entry, none if negative.
+	historyLimit int
+}
+
+// Open opens the password store file at the filepath "f", creating it if it does not exist.
+// "opts" can be nil to use the default options.
+func Open(f string, opts *Options) (*Vault, error) {
+	if !filepath.IsAbs(f) {
+		return nil, ErrFilePathNotAbsolute
+	}
+
+	if opts == nil {
+		opts = &Options{}
+	}
+
+	v := &Vault{
+		path:         f,
+		lockTimeout:  opts.LockTimeout,
+		kdfTime:      opts.KDFTime,
+		kdfMemory:    opts.KDFMemory,
+		kdfThreads:   opts.KDFThreads,
+		historyLimit: opts.HistoryLimit,
+	}
+
+	if v.lockTimeout == 0 {
+		v.lockTimeout = DefaultLockTimeout
+	}
+
+	if v.historyLimit == 0 {
+		v.historyLimit = DefaultHistoryLimit
+	}
+
+	if stat, err := os.Stat(f); err == nil {
+		if !stat.IsDir() {
+			return v, nil
+		}
+	}
+
+	err := os.MkdirAll(filepath.Dir(f), dirPerm)
+	if err != nil {
+		return nil, wrap(err)
+	}
+
+	// O_EXCL makes sure that a vault created by another process meanwhile is not truncated.
+	// The existing file is opened instead, which still fails if it is a directory.
+	file, err := os.OpenFile(f, os.O_RDWR|os.O_CREATE|os.O_EXCL, filePerm)
+	if errors.Is(err, fs.ErrExist) {
+		file, err = os.OpenFile(f, os.O_RDWR, filePerm)
+	}
+
+	if err != nil {
+		return nil, wrap(err)
+	}
+
+	err = file.Close()
+	if err != nil {
+		return nil, wrap(err)
+	}
+
+	return v, nil
+}
+
+// Path returns the filepath of the password store file.
+func (v *Vault) Path() string {
+	return v.path
+}
+
+// newKDFParams returns kdf params with a new salt and the cost parameters of the vault.
+func (v *Vault) newKDFParams() (crypto.KDFParams, error) {
+	params, err := crypto.NewKDFParams()
+	if err != nil {
+		return params, wrap(err)
+	}
+
+	if v.kdfTime != 0 {
+		params.Time = v.kdfTime
+	}
+
+	if v.kdfMemory != 0 {
+		params.Memory = v.kdfMemory
+	}
+
+	if v.kdfThreads != 0 {
+		params.Threads = v.kdfThreads
+	}
+
+	return params, nil
+}
+
+// readFile reads the password store file after checking its permissions.
+func (v *Vault) readFile() ([]byte, error) {
+	_, err := os.Stat(v.path)
+	if err != nil {
+		return nil, wrap(err)
+	}
+
+	err = checkPermissions(v.path)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(v.path)
+	if err != nil {
+		return nil, wrap(err)
+	}
+
+	return data, nil
+}
+
+// unlock decrypts the file with the password "p" and returns a session holding the derived key.
+// Files written in the legacy format are migrated to the current format.
+// It must be called with the lock held.
+func (v *Vault) unlock(p []byte) (*Session, error) {
+	data, err := v.readFile()
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Session{
+		v:     v,
+		store: newpasswdStore(),
+		data:  data,
+	}
+
+	if len(data) == 0 {
+		return s, nil
+	}
+
+	f, err := parseFileData(data)
+	if err != nil {
+		return nil, err
+	}
+
+	err = f.verifyChecksum()
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := crypto.DeriveKey(p, f.Header.KDF)
+	if f.Header.KDF.KDF == crypto.KDFLegacy && errors.Is(err, crypto.ErrInvalidKeySize) {
+		// legacy files could only be written with passwords of at most 32 bytes.
+		return nil, wrap(crypto.ErrWrongPasswd)
+	}
+
+	if err != nil {
+		return nil, wrap(err)
+	}
+
+	d, err := f.decrypt(key)
+	if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal(d, &s.store)
+	if err != nil {
+		return nil, wrap(err)
+	}
+
+	s.header, s.key = f.Header, key
+
+	migrate := f.Header.Version != formatVersion
+	if len(s.store.Passwd) != 0 {
+		s.store.Initialised = true
+		s.store.Passwd = nil
+		migrate = true
+	}
+
+	if migrate {
+		// entries of older versions were only passwords without any times, so they are dated to the migration.
+		now := time.Now().UTC()
+
+		for k, e := range s.store.Store {
+			if e.Created.IsZero() {
+				e.Created = now
+			}
+
+			if e.Modified.IsZero() {
+				e.Modified = now
+			}
+
+			s.store.Store[k] = e
+		}
+
+		err = s.setPasswd(p)
+		if err != nil {
+			return nil, err
+		}
+
+		err = s.commit()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return s, nil
+}
+
+// withLock runs "fn" while holding the lock on the password store file,
+// so that concurrent read-modify-write operations from other processes do not lose each other's writes.
+func (v *Vault) withLock(fn func() error) error {
+	l, err := lockFile(v.path, v.lockTimeout)
+	if err != nil {
+		return err
+	}
+
+	err = fn()
+
+	uerr := l.unlock()
+	if err == nil {
+		err = uerr
+	}
+
+	return err
+}
+
+// FixPermissions restricts the permissions of the password store file and its directory to the current user.
+func (v *Vault) FixPermissions() error {
+	err := os.Chmod(filepath.Dir(v.path), dirPerm)
+	if err != nil {
+		return wrap(err)
+	}
+
+	return wrap(os.Chmod(v.path, filePerm))
+}
+
+// Unlock decrypts the vault with the password "p" and returns a session which holds the derived key in memory.
+// The session can be used for many reads and writes which are written to the file together by Session.Commit.
+// Unlocking a vault whose password is not set yet returns an empty session on which Session.ChangePasswd has
+// to be called before committing.
+func (v *Vault) Unlock(p []byte) (*Session, error) {
+	var s *Session
+
+	err := v.withLock(func() error {
+		var err error
+		s, err = v.unlock(p)
+
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// update unlocks the vault with the password "p", runs "fn" on the session and commits it, all while holding the lock.
+func (v *Vault) update(p []byte, fn func(*Session) error) error {
+	return v.withLock(func() error {
+		s, err := v.unlock(p)
+		if err != nil {
+			return err
+		}
+
+		defer s.Close()
+
+		err = fn(s)
+		if err != nil {
+			return err
+		}
+
+		return s.commit()
+	})
+}
+
+// Update unlocks the vault with the password "p", runs "fn" on the session and commits the changes it made,
+// holding the lock on the file from the read to the write so that it waits for other processes instead of failing
+// with ErrVaultModified. Nothing is written if "fn" returns an error. The session can not be used after "fn" returns.
+func (v *Vault) Update(p []byte, fn func(*Session) error) error {
+	return v.update(p, fn)
+}
+
+// view unlocks the vault with the password "p" and runs "fn" on the session while holding the lock.
+func (v *Vault) view(p []byte, fn func(*Session) error) error {
+	return v.withLock(func() error {
+		s, err := v.unlock(p)
+		if err != nil {
+			return err
+		}
+
+		defer s.Close()
+
+		return fn(s)
+	})
+}
+
+// Get gets the key value pair from the store.
+// It fails with ErrEntryNotFound if there is no entry with the key "k".
+func (v *Vault) Get(k string, p []byte) (string, error) {
+	var value string
+
+	err := v.view(p, func(s *Session) error {
+		var err error
+		value, err = s.Get(k)
+
+		return err
+	})
+
+	return value, err
+}
+
+// GetEntry gets the entry from the store.
+// It fails with ErrEntryNotFound if there is no entry with the key "k".
+func (v *Vault) GetEntry(k string, p []byte) (Entry, error) {
+	var e Entry
+
+	err := v.view(p, func(s *Session) error {
+		var err error
+		e, err = s.GetEntry(k)
+
+		return err
+	})
+
+	return e, err
+}
+
+// PutEntry puts the entry in the store.
+func (v *Vault) PutEntry(k string, e Entry, p []byte) error {
+	return v.update(p, func(s *Session) error {
+		return s.PutEntry(k, e)
+	})
+}
+
+// Put puts the key value pair in the store.
+func (v *Vault) Put(k, val string, p []byte) error {
+	return v.update(p, func(s *Session) error {
+		return s.Put(k, val)
+	})
+}
+
+// ListKeys lists all the keys in the store sorted.
+func (v *Vault) ListKeys(p []byte) ([]string, error) {
+	var keys []string
+
+	err := v.view(p, func(s *Session) error {
+		var err error
+		keys, err = s.ListKeys()
+
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+// ListEntries lists all the key value pairs in the store.
+func (v *Vault) ListEntries(p []byte) ([][2]string, error) {
+	var entries [][2]string
+
+	err := v.view(p, func(s *Session) error {
+		var err error
+		entries, err = s.ListEntries()
+
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// Delete deletes the key value pair in the store.
+// It fails with ErrEntryNotFound if there is no entry with the key "k".
+func (v *Vault) Delete(k string, p []byte) error {
+	return v.update(p, func(s *Session) error {
+		return s.Delete(k)
+	})
+}
+
+// Rename renames the entry "from" to "to" with a single write.
+// It fails with ErrEntryExists if there is an entry "to" unless "overwrite" is set.
+func (v *Vault) Rename(from, to string, overwrite bool, p []byte) error {
+	return v.update(p, func(s *Session) error {
+		return s.Rename(from, to, overwrite)
+	})
+}
+
+// Copy copies the entry "from" to a new entry "to" with a single write.
+// It fails with ErrEntryExists if there is an entry "to" unless "overwrite" is set.
+func (v *Vault) Copy(from, to string, overwrite bool, p []byte) error {
+	return v.update(p, func(s *Session) error {
+		return s.Copy(from, to, overwrite)
+	})
+}
+
+// Restore restores the value of the version "n" in the history of the entry, where 1 is the most recent previous value.
+// The current value is kept in the history, so a restore can be undone.
+// It fails with ErrVersionNotFound if the history has no version "n".
+func (v *Vault) Restore(k string, n int, p []byte) error {
+	return v.update(p, func(s *Session) error {
+		return s.Restore(k, n)
+	})
+}
+
+// Clear clears all the key value pairs in the store.
+func (v *Vault) Clear(p []byte) error {
+	return v.update(p, func(s *Session) error {
+		return s.Clear()
+	})
+}
+
+// ChangePasswd changes the password for the store.
+func (v *Vault) ChangePasswd(np, op []byte) error {
+	return v.update(op, func(s *Session) error {
+		return s.ChangePasswd(np)
+	})
+}
@@ -0,0 +1,9 @@
+/*
+Package strength estimates the strength of passwords like zxcvbn.
+A password is split into the sequence of patterns which is the easiest to guess,
+like common passwords, english words, keyboard patterns, sequences, repeats and dates,
+with brute force for the rest. The number of guesses needed for the sequence gives the score from 0 to 4,
+along with feedback explaining the weaknesses found. Only the first 100 characters of a password are estimated.
+The common passwords and english words are the frequency lists of zxcvbn which are licensed under the MIT license.
+*/
+package strength
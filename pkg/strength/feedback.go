@@ -0,0 +1,109 @@
+package strength
+
+import (
+	"strings"
+	"unicode"
+)
+
+// defaultSuggestion is given for every weak password.
+const defaultSuggestion = "Add another word or two. Uncommon words are better."
+
+// feedback returns the warning and suggestions for a password with the score and sequence of matches.
+// Strong passwords get no feedback, weak ones get feedback about their longest match.
+func feedback(score int, seq []*match) (string, []string) {
+	if len(seq) == 0 {
+		return "", []string{
+			"Use a few words, avoid common phrases.",
+			"No need for symbols, digits or uppercase letters.",
+		}
+	}
+
+	if score > 2 {
+		return "", nil
+	}
+
+	longest := seq[0]
+	for _, m := range seq[1:] {
+		if len(m.token) > len(longest.token) {
+			longest = m
+		}
+	}
+
+	warning, suggestions := matchFeedback(longest, len(seq) == 1)
+
+	return warning, append([]string{defaultSuggestion}, suggestions...)
+}
+
+// matchFeedback returns the warning and suggestions for a match, which is the whole password if "whole" is true.
+func matchFeedback(m *match, whole bool) (string, []string) {
+	switch m.pattern {
+	case patternDictionary:
+		return dictionaryFeedback(m, whole)
+	case patternSpatial:
+		warning := "Short keyboard patterns are easy to guess."
+		if m.turns == 1 {
+			warning = "Straight rows of keys are easy to guess."
+		}
+
+		return warning, []string{"Use a longer keyboard pattern with more turns."}
+	case patternRepeat:
+		warning := `Repeats like "abcabcabc" are only slightly harder to guess than "abc".`
+		if len([]rune(m.base)) == 1 {
+			warning = `Repeats like "aaa" are easy to guess.`
+		}
+
+		return warning, []string{"Avoid repeated words and characters."}
+	case patternSequence:
+		return "Sequences like abc or 6543 are easy to guess.", []string{"Avoid sequences."}
+	case patternDate:
+		if !m.date {
+			return "Recent years are easy to guess.", []string{
+				"Avoid recent years.",
+				"Avoid years that are associated with you.",
+			}
+		}
+
+		return "Dates are often easy to guess.", []string{"Avoid dates and years that are associated with you."}
+	default:
+		return "", nil
+	}
+}
+
+// dictionaryFeedback returns the warning and suggestions for a dictionary match.
+func dictionaryFeedback(m *match, whole bool) (string, []string) {
+	var warning string
+
+	switch {
+	case m.dict == dictPasswords && whole && !m.l33t && !m.reversed && m.rank <= 10:
+		warning = "This is a top-10 common password."
+	case m.dict == dictPasswords && whole && !m.l33t && !m.reversed && m.rank <= 100:
+		warning = "This is a top-100 common password."
+	case m.dict == dictPasswords:
+		warning = "This is similar to a commonly used password."
+	case m.dict == dictInputs:
+		warning = "Passwords based on the name of the entry or your user name are easy to guess."
+	case whole:
+		warning = "A word by itself is easy to guess."
+	}
+
+	var suggestions []string
+
+	runes := []rune(m.token)
+
+	switch {
+	case strings.ToUpper(m.token) == m.token && strings.ToLower(m.token) != m.token:
+		suggestions = append(suggestions, "All-uppercase is almost as easy to guess as all-lowercase.")
+	case unicode.IsUpper(runes[0]):
+		suggestions = append(suggestions, "Capitalization doesn't help very much.")
+	}
+
+	if m.reversed {
+		suggestions = append(suggestions, "Reversed words aren't much harder to guess.")
+	}
+
+	if m.l33t {
+		suggestions = append(suggestions, "Predictable substitutions like '@' instead of 'a' don't help very much.")
+	}
+
+	return warning, suggestions
+}
@@ -0,0 +1,842 @@
+package strength
+
+import (
+	_ "embed"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// Patterns of the matches.
+const (
+	patternDictionary = "dictionary"
+	patternSpatial    = "spatial"
+	patternSequence   = "sequence"
+	patternRepeat     = "repeat"
+	patternDate       = "date"
+	patternBruteforce = "bruteforce"
+)
+
+// Dictionaries of the dictionary matches.
+const (
+	dictPasswords = "passwords"
+	dictEnglish   = "english"
+	dictInputs    = "inputs"
+)
+
+// dictNames are the dictionaries in the order they are matched.
+var dictNames = []string{dictInputs, dictPasswords, dictEnglish}
+
+const (
+	// maxWordLength is the length of the longest word matched in the dictionaries.
+	maxWordLength = 25
+	// maxSequenceDelta is the largest difference between consecutive characters of a sequence like "aceg".
+	maxSequenceDelta = 5
+	// minYearSpace is the minimum number of years guessed around the current year.
+	minYearSpace = 20
+	// minYear and maxYear are the years matched in dates.
+	minYear = 1000
+	maxYear = 2050
+	// maxL33tSubs is the maximum number of l33t substitution tables tried for a password.
+	maxL33tSubs = 64
+)
+
+//go:embed passwords.txt
+var passwordsList string
+
+//go:embed english.txt
+var englishList string
+
+var (
+	dictsOnce sync.Once
+	// dicts are the ranks of the words of the dictionaries, starting from 1 for the most common word.
+	dicts map[string]map[string]int
+)
+
+// rankedDicts returns the ranked dictionaries of the embedded frequency lists.
+func rankedDicts() map[string]map[string]int {
+	dictsOnce.Do(func() {
+		dicts = map[string]map[string]int{
+			dictPasswords: rank(strings.Fields(passwordsList)),
+			dictEnglish:   rank(strings.Fields(englishList)),
+		}
+	})
+
+	return dicts
+}
+
+// rank returns the ranks of the words in the order given.
+func rank(words []string) map[string]int {
+	ranks := make(map[string]int, len(words))
+
+	for i, w := range words {
+		w = strings.ToLower(w)
+		if _, ok := ranks[w]; !ok {
+			ranks[w] = i + 1
+		}
+	}
+
+	return ranks
+}
+
+// l33tTable maps letters to the characters commonly substituted for them.
+var l33tTable = map[rune]string{
+	'a': "4@",
+	'b': "8",
+	'c': "({[<",
+	'e': "3",
+	'g': "69",
+	'i': "1!|",
+	'l': "1|7",
+	'o': "0",
+	's': "$5",
+	't': "+7",
+	'x': "%",
+	'z': "2",
+}
+
+// match is a part of the password, from the character "i" to "j", matching a pattern.
+type match struct {
+	pattern string
+	i, j    int
+	token   string
+	guesses float64
+
+	// dict, rank, reversed and l33t are set for dictionary matches.
+	dict     string
+	rank     int
+	reversed bool
+	l33t     bool
+
+	// turns and shifted are set for spatial matches.
+	turns   int
+	shifted int
+
+	// base and count are set for repeat matches.
+	base  string
+	count int
+
+	// year, date and separator are set for date matches, which are only years if date is false.
+	year      int
+	date      bool
+	separator bool
+}
+
+// matches returns the matches of all the patterns in the password.
+func matches(pwd []rune, inputs []string) []*match {
+	ds := map[string]map[string]int{}
+	for k, v := range rankedDicts() {
+		ds[k] = v
+	}
+
+	if len(inputs) > 0 {
+		ds[dictInputs] = rank(inputs)
+	}
+
+	var ms []*match
+
+	ms = append(ms, dictionaryMatches(pwd, ds)...)
+	ms = append(ms, reversedMatches(pwd, ds)...)
+	ms = append(ms, l33tMatches(pwd, ds)...)
+	ms = append(ms, spatialMatches(pwd)...)
+	ms = append(ms, sequenceMatches(pwd)...)
+	ms = append(ms, repeatMatches(pwd, inputs)...)
+	ms = append(ms, dateMatches(pwd)...)
+
+	return ms
+}
+
+// uppercaseVariations returns the number of ways the word can be capitalized like the token.
+func uppercaseVariations(token string) float64 {
+	lower, upper := 0, 0
+
+	for _, r := range token {
+		switch {
+		case unicode.IsLower(r):
+			lower++
+		case unicode.IsUpper(r):
+			upper++
+		}
+	}
+
+	if upper == 0 {
+		return 1
+	}
+
+	runes := []rune(token)
+	first, last := unicode.IsUpper(runes[0]), unicode.IsUpper(runes[len(runes)-1])
+
+	// capitalizing the first or last letter or all of them are the most common, so count them as one bit.
+	if lower == 0 || (upper == 1 && (first || last)) {
+		return 2
+	}
+
+	variations := 0.0
+	for i := 1; i <= lower && i <= upper; i++ {
+		variations += binomial(upper+lower, i)
+	}
+
+	return variations
+}
+
+// dictionaryMatches returns the words of the dictionaries in the password ignoring the case.
+func dictionaryMatches(pwd []rune, ds map[string]map[string]int) []*match {
+	lower := []rune(strings.ToLower(string(pwd)))
+	if len(lower) != len(pwd) {
+		lower = pwd
+	}
+
+	var ms []*match
+
+	for i := range lower {
+		for j := i; j < len(lower) && j-i < maxWordLength; j++ {
+			word := string(lower[i : j+1])
+
+			for _, name := range dictNames {
+				r, ok := ds[name][word]
+				if !ok {
+					continue
+				}
+
+				token := string(pwd[i : j+1])
+				ms = append(ms, &match{
+					pattern: patternDictionary,
+					i:       i,
+					j:       j,
+					token:   token,
+					dict:    name,
+					rank:    r,
+					guesses: float64(r) * uppercaseVariations(token),
+				})
+			}
+		}
+	}
+
+	return ms
+}
+
+// reversedMatches returns the words of the dictionaries in the reversed password.
+func reversedMatches(pwd []rune, ds map[string]map[string]int) []*match {
+	n := len(pwd)
+	reversed := make([]rune, n)
+
+	for i, r := range pwd {
+		reversed[n-1-i] = r
+	}
+
+	var ms []*match
+
+	for _, m := range dictionaryMatches(reversed, ds) {
+		// palindromes are already matched as they are.
+		if len(m.token) < 2 || string(pwd[n-1-m.j:n-m.i]) == m.token {
+			continue
+		}
+
+		m.i, m.j = n-1-m.j, n-1-m.i
+		m.token = string(pwd[m.i : m.j+1])
+		m.reversed = true
+		m.guesses *= 2
+		ms = append(ms, m)
+	}
+
+	return ms
+}
+
+// l33tSubs returns the substitution tables from the l33t characters in the password to letters.
+// A character which is substituted for more than one letter gives a table for each of them.
+func l33tSubs(pwd []rune) []map[rune]rune {
+	subs := []map[rune]rune{{}}
+
+	seen := make(map[rune]bool)
+
+	for _, c := range pwd {
+		if seen[c] {
+			continue
+		}
+
+		seen[c] = true
+
+		var letters []rune
+
+		for l, chars := range l33tTable {
+			if strings.ContainsRune(chars, c) {
+				letters = append(letters, l)
+			}
+		}
+
+		sort.Slice(letters, func(i, j int) bool {
+			return letters[i] < letters[j]
+		})
+
+		if len(letters) == 0 {
+			continue
+		}
+
+		next := make([]map[rune]rune, 0, len(subs)*len(letters))
+
+		for _, sub := range subs {
+			for _, l := range letters {
+				if len(next) >= maxL33tSubs {
+					break
+				}
+
+				temp := make(map[rune]rune, len(sub)+1)
+				for k, v := range sub {
+					temp[k] = v
+				}
+
+				temp[c] = l
+				next = append(next, temp)
+			}
+		}
+
+		subs = next
+	}
+
+	return subs
+}
+
+// l33tVariations returns the number of ways the letters of the token can be substituted like the token.
+func l33tVariations(token []rune, sub map[rune]rune) float64 {
+	variations := 1.0
+
+	for c, l := range sub {
+		subbed, unsubbed := 0, 0
+
+		for _, r := range token {
+			switch unicode.ToLower(r) {
+			case c:
+				subbed++
+			case l:
+				unsubbed++
+			}
+		}
+
+		if subbed == 0 {
+			continue
+		}
+
+		if unsubbed == 0 {
+			variations *= 2
+
+			continue
+		}
+
+		possibilities := 0.0
+		for i := 1; i <= subbed && i <= unsubbed; i++ {
+			possibilities += binomial(subbed+unsubbed, i)
+		}
+
+		variations *= possibilities
+	}
+
+	return variations
+}
+
+// l33tMatches returns the words of the dictionaries in the password with l33t substitutions like "p4ssw0rd".
+func l33tMatches(pwd []rune, ds map[string]map[string]int) []*match {
+	var ms []*match
+
+	seen := make(map[[2]int]bool)
+
+	for _, sub := range l33tSubs(pwd) {
+		if len(sub) == 0 {
+			continue
+		}
+
+		subbed := make([]rune, len(pwd))
+
+		for i, r := range pwd {
+			subbed[i] = r
+			if l, ok := sub[r]; ok {
+				subbed[i] = l
+			}
+		}
+
+		for _, m := range dictionaryMatches(subbed, ds) {
+			token := pwd[m.i : m.j+1]
+			if len(token) < 2 || string(token) == m.token || seen[[2]int{m.i, m.j}] {
+				continue
+			}
+
+			used := make(map[rune]rune)
+
+			for _, r := range token {
+				if l, ok := sub[r]; ok {
+					used[r] = l
+				}
+			}
+
+			seen[[2]int{m.i, m.j}] = true
+			m.token = string(token)
+			m.l33t = true
+			m.guesses *= l33tVariations(token, used)
+			ms = append(ms, m)
+		}
+	}
+
+	return ms
+}
+
+// keyboard is the qwerty layout with the unshifted and shifted character of each key.
+// The rows are slanted, so each row starts half a key to the right of the one above.
+var keyboard = [][]string{
+	{"`~", "1!", "2@", "3#", "4$", "5%", "6^", "7&", "8*", "9(", "0)", "-_", "=+"},
+	{"qQ", "wW", "eE", "rR", "tT", "yY", "uU", "iI", "oO", "pP", "[{", "]}", "\\|"},
+	{"aA", "sS", "dD", "fF", "gG", "hH", "jJ", "kK", "lL", ";:", "'\""},
+	{"zZ", "xX", "cC", "vV", "bB", "nN", "mM", ",<", ".>", "/?"},
+}
+
+// keyPosition is the position of a key on the keyboard.
+type keyPosition struct {
+	x, y int
+}
+
+var (
+	keyboardOnce sync.Once
+	// keyPositions are the positions of the characters on the keyboard.
+	keyPositions map[rune]keyPosition
+	// keys are the keys at the positions of the keyboard.
+	keys map[keyPosition]string
+	// keyboardStarts is the number of characters on the keyboard and
+	// keyboardDegree is the average number of keys next to a key.
+	keyboardStarts float64
+	keyboardDegree float64
+)
+
+// initKeyboard sets the positions of the keys of the keyboard.
+func initKeyboard() {
+	keyboardOnce.Do(func() {
+		keyPositions = make(map[rune]keyPosition)
+		keys = make(map[keyPosition]string)
+
+		for y, row := range keyboard {
+			for i, key := range row {
+				p := keyPosition{x: i, y: y}
+				if y > 0 {
+					p.x++
+				}
+
+				keys[p] = key
+				for _, r := range key {
+					keyPositions[r] = p
+				}
+			}
+		}
+
+		neighbours := 0
+		for p := range keys {
+			neighbours += len(adjacentKeys(p))
+		}
+
+		keyboardStarts = float64(len(keyPositions))
+		keyboardDegree = float64(neighbours) / float64(len(keys))
+	})
+}
+
+// adjacentKeys returns the keys next to the position in a fixed order of directions,
+// with empty strings for the directions which have no key.
+func adjacentKeys(p keyPosition) []string {
+	dirs := []keyPosition{{-1, 0}, {0, -1}, {1, -1}, {1, 0}, {0, 1}, {-1, 1}}
+	adj := make([]string, 0, len(dirs))
+
+	for _, d := range dirs {
+		adj = append(adj, keys[keyPosition{x: p.x + d.x, y: p.y + d.y}])
+	}
+
+	return adj
+}
+
+// isShifted reports if the character needs the shift key.
+func isShifted(r rune) bool {
+	p, ok := keyPositions[r]
+
+	return ok && []rune(keys[p])[1] == r
+}
+
+// spatialGuesses returns the guesses of a keyboard pattern of "l" characters with the turns and shifted characters.
+func spatialGuesses(l, turns, shifted int) float64 {
+	guesses := 0.0
+
+	for i := 2; i <= l; i++ {
+		for j := 1; j <= turns && j <= i-1; j++ {
+			guesses += binomial(i-1, j-1) * keyboardStarts * math.Pow(keyboardDegree, float64(j))
+		}
+	}
+
+	unshifted := l - shifted
+
+	switch {
+	case shifted == 0:
+	case unshifted == 0:
+		guesses *= 2
+	default:
+		variations := 0.0
+		for i := 1; i <= shifted && i <= unshifted; i++ {
+			variations += binomial(shifted+unshifted, i)
+		}
+
+		guesses *= variations
+	}
+
+	return guesses
+}
+
+// spatialMatches returns the runs of 3 or more adjacent keys on the keyboard like "qwerty" or "zxcvfr".
+func spatialMatches(pwd []rune) []*match {
+	initKeyboard()
+
+	var ms []*match
+
+	for i := 0; i < len(pwd)-1; {
+		j := i + 1
+		dir, turns, shifted := -1, 0, 0
+
+		if isShifted(pwd[i]) {
+			shifted++
+		}
+
+		for ; j < len(pwd); j++ {
+			p, ok := keyPositions[pwd[j-1]]
+			if !ok {
+				break
+			}
+
+			found := -1
+
+			for d, key := range adjacentKeys(p) {
+				k := strings.IndexRune(key, pwd[j])
+				if k < 0 {
+					continue
+				}
+
+				found = d
+
+				if k > 0 {
+					shifted++
+				}
+
+				break
+			}
+
+			if found < 0 {
+				break
+			}
+
+			if found != dir {
+				turns++
+				dir = found
+			}
+		}
+
+		if j-i > 2 {
+			ms = append(ms, &match{
+				pattern: patternSpatial,
+				i:       i,
+				j:       j - 1,
+				token:   string(pwd[i:j]),
+				turns:   turns,
+				shifted: shifted,
+				guesses: spatialGuesses(j-i, turns, shifted),
+			})
+		}
+
+		i = j
+	}
+
+	return ms
+}
+
+// sequenceGuesses returns the guesses of a sequence like "abcd" or "9753".
+func sequenceGuesses(token []rune, ascending bool) float64 {
+	var base float64
+
+	switch first := token[0]; {
+	case strings.ContainsRune("aAzZ019", first):
+		base = 4
+	case unicode.IsDigit(first):
+		base = 10
+	default:
+		base = 26
+	}
+
+	if !ascending {
+		base *= 2
+	}
+
+	return base * float64(len(token))
+}
+
+// sequenceMatches returns the runs of characters with the same difference between them like "abcd", "1357" or "zyx".
+func sequenceMatches(pwd []rune) []*match {
+	var ms []*match
+
+	add := func(i, j, delta int) {
+		if (j-i > 1 || abs(delta) == 1) && delta != 0 && abs(delta) <= maxSequenceDelta {
+			token := pwd[i : j+1]
+			ms = append(ms, &match{
+				pattern: patternSequence,
+				i:       i,
+				j:       j,
+				token:   string(token),
+				guesses: sequenceGuesses(token, delta > 0),
+			})
+		}
+	}
+
+	if len(pwd) < 2 {
+		return nil
+	}
+
+	i, last := 0, int(pwd[1]-pwd[0])
+
+	for k := 2; k < len(pwd); k++ {
+		delta := int(pwd[k] - pwd[k-1])
+		if delta == last {
+			continue
+		}
+
+		add(i, k-1, last)
+		i, last = k-1, delta
+	}
+
+	add(i, len(pwd)-1, last)
+
+	return ms
+}
+
+func abs(i int) int {
+	if i < 0 {
+		return -i
+	}
+
+	return i
+}
+
+// repeatMatches returns the runs of a repeated string like "aaa" or "abcabc".
+// For each start the longest run with the shortest repeated string is taken.
+func repeatMatches(pwd []rune, inputs []string) []*match {
+	var ms []*match
+
+	for i := 0; i < len(pwd); {
+		bestLen, bestUnit := 0, 0
+
+		for u := 1; i+2*u <= len(pwd); u++ {
+			count := 1
+			for i+(count+1)*u <= len(pwd) && string(pwd[i+count*u:i+(count+1)*u]) == string(pwd[i:i+u]) {
+				count++
+			}
+
+			if count > 1 && count*u > bestLen {
+				bestLen, bestUnit = count*u, u
+			}
+		}
+
+		if bestLen == 0 {
+			i++
+
+			continue
+		}
+
+		base := string(pwd[i : i+bestUnit])
+		count := bestLen / bestUnit
+		ms = append(ms, &match{
+			pattern: patternRepeat,
+			i:       i,
+			j:       i + bestLen - 1,
+			token:   string(pwd[i : i+bestLen]),
+			base:    base,
+			count:   count,
+			guesses: Estimate(base, inputs...).Guesses * float64(count),
+		})
+		i += bestLen
+	}
+
+	return ms
+}
+
+// yearSpace returns the number of years an attacker guesses to reach the year from the current year.
+func yearSpace(year int) float64 {
+	return math.Max(math.Abs(float64(year-time.Now().Year())), minYearSpace)
+}
+
+// twoToFourDigitYear returns the year of a two digit year like 87 or 12.
+func twoToFourDigitYear(year int) int {
+	switch {
+	case year > 99:
+		return year
+	case year > 50:
+		return year + 1900
+	default:
+		return year + 2000
+	}
+}
+
+// dayMonth reports if the numbers are a day and a month in any order.
+func dayMonth(a, b int) bool {
+	return (a >= 1 && a <= 31 && b >= 1 && b <= 12) || (b >= 1 && b <= 31 && a >= 1 && a <= 12)
+}
+
+// dateYear returns the year of the date of the numbers in the orders year-day-month or day-month-year,
+// preferring four digit years.
+func dateYear(a, b, c int) (int, bool) {
+	if b < 1 || b > 31 {
+		return 0, false
+	}
+
+	for _, n := range []int{a, b, c} {
+		if (n > 99 && n < minYear) || n > maxYear {
+			return 0, false
+		}
+	}
+
+	splits := [][3]int{{c, a, b}, {a, b, c}}
+
+	for _, s := range splits {
+		if s[0] >= minYear && s[0] <= maxYear && dayMonth(s[1], s[2]) {
+			return s[0], true
+		}
+	}
+
+	for _, s := range splits {
+		if s[0] <= 99 && dayMonth(s[1], s[2]) {
+			return twoToFourDigitYear(s[0]), true
+		}
+	}
+
+	return 0, false
+}
+
+// atoi returns the number of the digits.
+func atoi(digits []rune) int {
+	n := 0
+	for _, r := range digits {
+		n = n*10 + int(r-'0')
+	}
+
+	return n
+}
+
+// isDigits reports if all the characters are ascii digits.
+func isDigits(rs []rune) bool {
+	for _, r := range rs {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+
+	return len(rs) > 0
+}
+
+// dateMatches returns the years like "1987" and dates like "1987-04-13" or "130487" in the password.
+func dateMatches(pwd []rune) []*match {
+	var ms []*match
+
+	add := func(i, j, year int, date, separator bool) {
+		guesses := yearSpace(year)
+
+		if date {
+			guesses *= 365
+		}
+
+		if separator {
+			guesses *= 4
+		}
+
+		ms = append(ms, &match{
+			pattern:   patternDate,
+			i:         i,
+			j:         j,
+			token:     string(pwd[i : j+1]),
+			year:      year,
+			date:      date,
+			separator: separator,
+			guesses:   guesses,
+		})
+	}
+
+	for i := range pwd {
+		// years.
+		if i+4 <= len(pwd) && isDigits(pwd[i:i+4]) {
+			if year := atoi(pwd[i : i+4]); year >= 1900 && year <= 2099 {
+				add(i, i+3, year, false, false)
+			}
+		}
+
+		// dates without separators of 4 to 8 digits.
+		for l := 4; l <= 8 && i+l <= len(pwd); l++ {
+			token := pwd[i : i+l]
+			if !isDigits(token) {
+				break
+			}
+
+			found, bestYear := false, 0
+
+			for k := 1; k < l-1; k++ {
+				for m := k + 1; m < l && m-k <= 2; m++ {
+					if k > 4 || l-m > 4 {
+						continue
+					}
+
+					year, ok := dateYear(atoi(token[:k]), atoi(token[k:m]), atoi(token[m:]))
+					if ok && (!found || yearSpace(year) < yearSpace(bestYear)) {
+						found, bestYear = true, year
+					}
+				}
+			}
+
+			if found {
+				add(i, i+l-1, bestYear, true, false)
+			}
+		}
+
+		// dates with separators like 13/04/1987.
+		for l := 6; l <= 10 && i+l <= len(pwd); l++ {
+			token := pwd[i : i+l]
+
+			parts := strings.FieldsFunc(string(token), func(r rune) bool {
+				return strings.ContainsRune(" /\\_.-", r)
+			})
+			if len(parts) != 3 {
+				continue
+			}
+
+			seps := strings.Map(func(r rune) rune {
+				if strings.ContainsRune(" /\\_.-", r) {
+					return r
+				}
+
+				return -1
+			}, string(token))
+			if len(seps) != 2 || seps[0] != seps[1] || len(parts[0])+len(parts[1])+len(parts[2])+2 != l {
+				continue
+			}
+
+			valid := true
+			nums := make([]int, 0, len(parts))
+
+			for _, p := range parts {
+				if !isDigits([]rune(p)) || len(p) > 4 {
+					valid = false
+
+					break
+				}
+
+				nums = append(nums, atoi([]rune(p)))
+			}
+
+			if !valid || len(parts[1]) > 2 {
+				continue
+			}
+
+			if year, ok := dateYear(nums[0], nums[1], nums[2]); ok {
+				add(i, i+l-1, year, true, true)
+			}
+		}
+	}
+
+	return ms
+}
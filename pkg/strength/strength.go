@@ -0,0 +1,212 @@
+package strength
+
+import (
+	"math"
+)
+
+const (
+	// MaxScore is the score of very unguessable passwords.
+	MaxScore = 4
+	// maxLength is the number of characters of a password which are estimated.
+	// The characters after it are ignored so that long passwords are estimated quickly,
+	// as counting them as brute force would make a long run of a trivial pattern unguessable.
+	maxLength = 100
+	// minGuessesBeforeGrowingSequence is added to the guesses of sequences of more than one pattern
+	// so that a password is not split into many small patterns.
+	minGuessesBeforeGrowingSequence = 10000
+	// minSubmatchGuessesSingleChar and minSubmatchGuessesMultiChar are the minimum guesses of a pattern
+	// which is only a part of the password.
+	minSubmatchGuessesSingleChar = 10
+	minSubmatchGuessesMultiChar  = 50
+	// bruteforceCardinality is the number of guesses per character of brute force.
+	bruteforceCardinality = 10
+)
+
+// scoreThresholds are the guesses below which a password gets the score of the index.
+// They correspond to online attacks with and without throttling and offline attacks with slow and fast hashing.
+var scoreThresholds = []float64{1e3, 1e6, 1e8, 1e10}
+
+// Result is the estimated strength of a password.
+type Result struct {
+	// Score is from 0, too guessable, to MaxScore, very unguessable.
+	Score int
+	// Guesses is the estimated number of guesses needed to guess the password.
+	Guesses float64
+	// Warning explains the weakness of the password, if any.
+	Warning string
+	// Suggestions are the ways to make the password stronger.
+	Suggestions []string
+}
+
+// Estimate estimates the strength of the password "p".
+// The inputs are words the password should not be based on, like the user name or the name of the entry.
+// Only the first 100 characters of the password are estimated.
+func Estimate(p string, inputs ...string) Result {
+	pwd := []rune(p)
+	if len(pwd) > maxLength {
+		pwd = pwd[:maxLength]
+	}
+
+	seq, guesses := mostGuessableSequence(pwd, matches(pwd, inputs))
+
+	r := Result{
+		Score:   score(guesses),
+		Guesses: guesses,
+	}
+
+	r.Warning, r.Suggestions = feedback(r.Score, seq)
+
+	return r
+}
+
+// score returns the score of the guesses.
+func score(guesses float64) int {
+	// delta makes the guesses of a pattern at the threshold fall below it.
+	const delta = 5
+
+	for i, t := range scoreThresholds {
+		if guesses < t+delta {
+			return i
+		}
+	}
+
+	return MaxScore
+}
+
+// factorial returns n! as a float.
+func factorial(n int) float64 {
+	f := 1.0
+	for i := 2; i <= n; i++ {
+		f *= float64(i)
+	}
+
+	return f
+}
+
+// binomial returns the binomial coefficient "n" choose "k" as a float.
+func binomial(n, k int) float64 {
+	if k > n || k < 0 {
+		return 0
+	}
+
+	r := 1.0
+	for i := 1; i <= k; i++ {
+		r *= float64(n - k + i)
+		r /= float64(i)
+	}
+
+	return r
+}
+
+// bruteforce returns the brute force match of the characters from "i" to "j" of the password.
+func bruteforce(pwd []rune, i, j int) *match {
+	m := &match{pattern: patternBruteforce, i: i, j: j, token: string(pwd[i : j+1])}
+	m.guesses = math.Pow(bruteforceCardinality, float64(j-i+1))
+
+	return m
+}
+
+// guesses returns the guesses of the match, which are at least the minimum guesses of a part of the password.
+func guesses(m *match, n int) float64 {
+	l := m.j - m.i + 1
+	if m.pattern == patternBruteforce || l == n {
+		return math.Max(m.guesses, 1)
+	}
+
+	minGuesses := float64(minSubmatchGuessesMultiChar)
+	if l == 1 {
+		minGuesses = minSubmatchGuessesSingleChar
+	}
+
+	return math.Max(m.guesses, minGuesses)
+}
+
+// mostGuessableSequence returns the sequence of non overlapping matches covering the password
+// which needs the least number of guesses along with the guesses.
+// The guesses of a sequence of l matches are l! times the product of the guesses of the matches,
+// as the attacker has to try the patterns in any order, plus minGuessesBeforeGrowingSequence^(l-1).
+func mostGuessableSequence(pwd []rune, ms []*match) ([]*match, float64) {
+	n := len(pwd)
+	if n == 0 {
+		return nil, 1
+	}
+
+	byEnd := make([][]*match, n)
+	for _, m := range ms {
+		byEnd[m.j] = append(byEnd[m.j], m)
+	}
+
+	// best[k][l] is the best match ending at "k" for a sequence of "l" matches covering the password till "k",
+	// pi[k][l] is the product of the guesses of that sequence and g[k][l] is its total guesses.
+	best := make([]map[int]*match, n)
+	pi := make([]map[int]float64, n)
+	g := make([]map[int]float64, n)
+
+	for k := range best {
+		best[k], pi[k], g[k] = make(map[int]*match), make(map[int]float64), make(map[int]float64)
+	}
+
+	update := func(m *match, l int) {
+		k := m.j
+		p := guesses(m, n)
+
+		if l > 1 {
+			p *= pi[m.i-1][l-1]
+		}
+
+		total := factorial(l)*p + math.Pow(minGuessesBeforeGrowingSequence, float64(l-1))
+
+		for cl, cg := range g[k] {
+			if cl <= l && cg <= total {
+				return
+			}
+		}
+
+		best[k][l], pi[k][l], g[k][l] = m, p, total
+	}
+
+	for k := 0; k < n; k++ {
+		for _, m := range byEnd[k] {
+			if m.i == 0 {
+				update(m, 1)
+
+				continue
+			}
+
+			for l := range best[m.i-1] {
+				update(m, l+1)
+			}
+		}
+
+		update(bruteforce(pwd, 0, k), 1)
+
+		for i := 1; i <= k; i++ {
+			m := bruteforce(pwd, i, k)
+
+			for l, last := range best[i-1] {
+				// consecutive brute force matches are always worse than a single one.
+				if last.pattern != patternBruteforce {
+					update(m, l+1)
+				}
+			}
+		}
+	}
+
+	l, total := 0, math.Inf(1)
+
+	for cl, cg := range g[n-1] {
+		if cg < total || (cg == total && cl < l) {
+			l, total = cl, cg
+		}
+	}
+
+	seq := make([]*match, l)
+
+	for k := n - 1; l > 0; l-- {
+		m := best[k][l]
+		seq[l-1] = m
+		k = m.i - 1
+	}
+
+	return seq, total
+}
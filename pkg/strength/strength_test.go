@@ -0,0 +1,138 @@
+package strength_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/231tr0n/vault/pkg/strength"
+)
+
+func failTestCase(t *testing.T, i, o, w any) {
+	t.Helper()
+	t.Error("Input:", i, "|", "Output:", o, "|", "Want:", w)
+}
+
+func TestEstimate(t *testing.T) {
+	t.Parallel()
+
+	type test struct {
+		passwd string
+		score  int
+		// warning is a part of the warning expected.
+		warning string
+	}
+
+	tests := []test{
+		{"", 0, ""},
+		{"a", 0, "word by itself"},
+		{"password", 0, "top-10 common password"},
+		{"iloveyou", 0, "top-100 common password"},
+		{"P@ssw0rd", 0, "similar to a commonly used password"},
+		{"drowssap", 0, "similar to a commonly used password"},
+		{"zxcvbnm,./", 1, "Straight rows of keys"},
+		{"zxcvfr", 1, "Short keyboard patterns"},
+		{"abcdefgh", 0, "Sequences"},
+		{"aaaaaaa", 0, `Repeats like "aaa"`},
+		{"abcabcabc", 0, `Repeats like "abcabcabc"`},
+		{"1987", 0, "Recent years"},
+		{"13/04/1987", 1, "Dates"},
+		{"13041987", 1, "Dates"},
+		{"github", 0, "name of the entry"},
+		{"correcthorsebatterystaple", 4, ""},
+		{"x9$Kq!7vLm@2Zp", 4, ""},
+		{"shiny-dejected-defy-abstain-morphine-campsite", 4, ""},
+	}
+
+	for _, test := range tests {
+		t.Log(test)
+		out := strength.Estimate(test.passwd, "github")
+
+		if out.Score != test.score || !strings.Contains(out.Warning, test.warning) {
+			failTestCase(t, test.passwd, out, test)
+		}
+
+		if out.Score <= 2 && len(out.Suggestions) == 0 {
+			failTestCase(t, test.passwd, out.Suggestions, "suggestions for weak passwords")
+		}
+
+		if out.Score > 2 && (out.Warning != "" || len(out.Suggestions) != 0) {
+			failTestCase(t, test.passwd, out, "no feedback for strong passwords")
+		}
+	}
+}
+
+func TestEstimateSuggestions(t *testing.T) {
+	t.Parallel()
+
+	tests := [][2]string{
+		{"Monkey", "Capitalization"},
+		{"MONKEY", "All-uppercase"},
+		{"yeknom", "Reversed"},
+		{"m0nk3y", "Predictable substitutions"},
+	}
+
+	for _, test := range tests {
+		t.Log(test)
+		out := strength.Estimate(test[0])
+
+		if !strings.Contains(strings.Join(out.Suggestions, " "), test[1]) {
+			failTestCase(t, test[0], out.Suggestions, test[1])
+		}
+	}
+}
+
+func TestEstimateGuessesGrow(t *testing.T) {
+	t.Parallel()
+
+	// guesses grow with the length of random passwords up to the length which is estimated.
+	random := "PtYgj*mUh#Bel31iEl2hpChYgCfrL1spNxnyVmihA@*2O76UMFxFkM&@R5Kjp%1vRt!1fjORS@6ilI8ihN5KXSc7Tvo@" +
+		"hBKqFYY@kv5ZJr3J1TWDtkwtDDb!xHKas1*VOq%g6YYZYn9ZhyiA4uoRgn"
+	prev := 0.0
+
+	for _, l := range []int{1, 5, 10, 50, 100} {
+		p := random[:l]
+		t.Log(p)
+		out := strength.Estimate(p)
+
+		if out.Guesses <= prev {
+			failTestCase(t, p, out.Guesses, prev)
+		}
+
+		prev = out.Guesses
+	}
+
+	// the characters after the length which is estimated don't add any guesses.
+	for _, l := range []int{101, 150} {
+		p := random[:l]
+		t.Log(p)
+		out := strength.Estimate(p)
+
+		if out.Guesses != prev {
+			failTestCase(t, p, out.Guesses, prev)
+		}
+	}
+}
+
+func TestEstimateLongPatterns(t *testing.T) {
+	t.Parallel()
+
+	// long runs of trivial patterns stay weak however long they are.
+	tests := []string{
+		strings.Repeat("a", 100),
+		strings.Repeat("a", 104),
+		strings.Repeat("a", 110),
+		strings.Repeat("a", 1000),
+		strings.Repeat("password", 13),
+		strings.Repeat("password", 50),
+		strings.Repeat("qwerty123", 40),
+	}
+
+	for _, test := range tests {
+		t.Log(len(test))
+		out := strength.Estimate(test)
+
+		if out.Score > 1 {
+			failTestCase(t, test, out.Score, "at most 1")
+		}
+	}
+}